@@ -1,5 +1,6 @@
 #!/bin/bash
 
+# ../gencsv is built by "go build ./cmd/gencsv" in the repo root
 pkgdir=./_output/go/src/genpkgs
 
 function genOne()
@@ -7,12 +8,11 @@ function genOne()
   local pkg=$1
 
   mkdir -p $pkgdir/$pkg
-  mkdir -p _doit _test
-  echo ../gencsv --Cfg $pkg.cfg --TestMain _test/test_$pkg.go --TestBash _doit/doit_$pkg.bash --Ofile $pkgdir/$pkg/$pkg.go --Pkg $pkg --Underscore end
-       ../gencsv --Cfg $pkg.cfg --TestMain _test/test_$pkg.go --TestBash _doit/doit_$pkg.bash --Ofile $pkgdir/$pkg/$pkg.go --Pkg $pkg --Underscore end
+  echo ../gencsv --Cfg $pkg.cfg --Ofile $pkgdir/$pkg/$pkg.go --Pkg $pkg --Underscore end
+       ../gencsv --Cfg $pkg.cfg --Ofile $pkgdir/$pkg/$pkg.go --Pkg $pkg --Underscore end
 
   echo "if it worked, please consider:"
-  echo "           git add $pkgdir/$pkg $pkg.cfg test/test_$pkg.go doit/doit_$pkg.bash"
+  echo "           git add $pkgdir/$pkg $pkg.cfg"
 }
 
 genOne foo1	# simple case (has one single key index)
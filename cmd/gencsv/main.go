@@ -0,0 +1,193 @@
+// gencsv is the commandline wrapper of package github.com/LDCS/gencsv.
+//
+// In GENCSV mode (--Cfg given) it writes the package for the spec file to --Ofile, with its tests to --Test.
+// Given --Infer, it proposes a spec file from a sample data file. Otherwise (GENCFG mode) it writes a spec file for the header in its argument.
+package main
+
+import (
+	"fmt"
+	"github.com/LDCS/gencsv"
+	"github.com/LDCS/sflag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var opt = struct {
+	Usage        string "generate bespoke package for an hcsv format"
+	Pkg          string "Name of the hcsv format, to be used as its package name"
+	CapsPkg      string "If left empty, it will be set ToUpper(Pkg)				|"
+	Cfg          string "Valid hcsv file holding spec of the target hcsv format, or its structured form if named *.json	|"
+	Convert      string "Filename to write the spec of Cfg to, in the structured form if named *.json, instead of generating its package	|"
+	Ofile        string "Filename for the generated package file"
+	Test         string "Filename for the tests of the package, or none; defaults to <pkg>_test.go next to Ofile	|"
+	Check        bool   "Only check that Ofile is what Cfg would generate, exiting 1 if it is stale"
+	HeaderStyle  string "Member variable names should be internal or external; internal if not set here or in the spec	|"
+	Underscore   string "Members should have (no or end) underscore; no if not set here or in the spec				|"
+	Delim        string "Field separator of the target format: comma, tab, pipe, semicolon or a single character; comma if not set here or in the spec	|"
+	Lint         string "Spec file to check, reporting each problem with its line number, instead of generating its package	|"
+	Infer        string "Sample data file, from which to propose a spec file (written to Ofile, or stdout)	|"
+	Rows         string "Number of data rows of the Infer sample file to scan		| 1000"
+	Module       string "Module the generated packages are in; they then import github.com/LDCS/genutil, and their go tests import ImportPrefix/<pkg>	|"
+	ImportPrefix string "Import path of the directory holding the package directory; defaults to Module, or anydset without Module	|"
+	Args         []string
+}{}
+
+// options returns the library options _base (the options section of a structured spec), overridden by the commandline parameters
+func options(_base gencsv.Options) gencsv.Options {
+	set := func(_to *string, _from string) {
+		if len(_from) > 0 {
+			*_to = _from
+		}
+	}
+	set(&_base.Pkg, opt.Pkg)
+	set(&_base.CapsPkg, opt.CapsPkg)
+	set(&_base.HeaderStyle, opt.HeaderStyle)
+	set(&_base.Underscore, opt.Underscore)
+	set(&_base.Delim, opt.Delim)
+	set(&_base.Module, opt.Module)
+	set(&_base.ImportPrefix, opt.ImportPrefix)
+	_base.Log = os.Stdout
+	return _base
+}
+
+// writeFile writes _src to _fname (unless it is none), panicking on _err, after writing _src to help locate the error
+func writeFile(_fname string, _src []byte, _err error) {
+	if _src != nil && _fname != "none" {
+		if err := os.WriteFile(_fname, _src, 0666); err != nil {
+			panic(err)
+		}
+	}
+	if _err != nil {
+		panic(_fname + ": " + _err.Error() + "\n" + opt.Usage)
+	}
+}
+
+// lint prints each problem of _spec, read from _fname, and exits with status 1 if there is any
+func lint(_spec *gencsv.Spec, _fname string) {
+	_spec.Options.Delim = options(_spec.Options).Delim // header names are checked against the field separator of the commandline
+	errs := _spec.Lint()
+	for _, se := range errs {
+		if se.Line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d: ", _fname, se.Line)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: ", _fname)
+		}
+		if len(se.Name) > 0 {
+			fmt.Fprintf(os.Stderr, "%s: ", se.Name)
+		}
+		fmt.Fprintln(os.Stderr, se.Err)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// convert writes _spec to the Convert file, with the options of the commandline
+func convert(_spec *gencsv.Spec) {
+	_spec.Options = options(_spec.Options)
+	_spec.Options.Log = nil
+	ff, err := os.Create(opt.Convert)
+	if err != nil {
+		panic(err)
+	}
+	if strings.HasSuffix(opt.Convert, ".json") {
+		err = _spec.WriteJSON(ff)
+	} else {
+		err = _spec.WriteCSV(ff)
+	}
+	if err == nil {
+		err = ff.Close()
+	}
+	if err != nil {
+		panic(opt.Convert + ": " + err.Error())
+	}
+	fmt.Println("gencsv --Convert:", opt.Cfg, "written to", opt.Convert)
+}
+
+// check exits with status 1 if Ofile is missing, or was not generated from _spec with the current options
+func check(_spec *gencsv.Spec) {
+	src, err := os.ReadFile(opt.Ofile)
+	if err == nil {
+		err = gencsv.Check(_spec, options(_spec.Options), src)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gencsv --Check:", opt.Ofile, "from", opt.Cfg+":", err)
+		os.Exit(1)
+	}
+	fmt.Println("gencsv --Check:", opt.Ofile, "is up to date")
+}
+
+func main() {
+	sflag.Parse(&opt)
+	if len(opt.Cfg) > 0 {
+		fmt.Println("gencsv ============================================================================================= starting")
+
+		spec, err := gencsv.LoadSpecFile(opt.Cfg)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(" numread=", spec.Numread, "numbad=", spec.Numbad, "numempty=", spec.Numempty, "numcomment=", spec.Numcomment)
+		if len(opt.Convert) > 0 {
+			convert(spec)
+			return
+		}
+		lint(spec, opt.Cfg)
+
+		if opt.Check {
+			check(spec)
+			return
+		}
+		gopt := options(spec.Options)
+		src, err := gencsv.Generate(spec, gopt)
+		writeFile(opt.Ofile, src, err)
+		if len(opt.Test) < 1 {
+			opt.Test = filepath.Join(filepath.Dir(opt.Ofile), gopt.Pkg+"_test.go")
+		}
+		test, err := gencsv.GenerateTest(spec, gopt)
+		writeFile(opt.Test, test, err)
+		fmt.Println("gencsv ============================================================================================= done")
+	} else if len(opt.Lint) > 0 {
+		spec, err := gencsv.LoadSpecFile(opt.Lint)
+		if err != nil {
+			panic(err)
+		}
+		lint(spec, opt.Lint)
+		fmt.Println("gencsv --Lint:", opt.Lint, "is ok")
+	} else if len(opt.Infer) > 0 {
+		// Propose a spec file from the header and first rows of a sample data file
+		maxrows, err := strconv.Atoi(opt.Rows)
+		if err != nil || maxrows < 1 {
+			panic("Rows must be a positive number, not " + opt.Rows + "\n" + opt.Usage)
+		}
+		rr, err := os.Open(opt.Infer)
+		if err != nil {
+			panic(err)
+		}
+		defer rr.Close()
+		fo := io.Writer(os.Stdout)
+		if len(opt.Ofile) > 0 {
+			ff, err := os.Create(opt.Ofile)
+			if err != nil {
+				panic(err)
+			}
+			defer ff.Close()
+			fo = ff
+		}
+		candidates, err := gencsv.Infer(fo, rr, options(gencsv.Options{}), maxrows)
+		if err != nil {
+			panic(opt.Infer + ": " + err.Error())
+		}
+		fmt.Fprintln(os.Stderr, "gencsv: string and int64 columns with unique values, candidates for an index:", strings.Join(candidates, ","))
+	} else {
+		// Assume being called in gencfg mode - creates spec file from the single csv parameter
+		fmt.Println("name,headerstring,type,hasindex,finaltype")
+		parts := strings.Split(os.Args[1], ",")
+		for _, row1 := range parts {
+			str := gencsv.MemberName(row1) + "," + row1 + ",,,"
+			fmt.Println(str)
+		}
+	}
+}
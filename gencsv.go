@@ -1,10 +1,11 @@
 // gencsv generates a package specific to the hcsv file format described in its input.
 //
 // An hcsv file is a csv file that
-//   (1) uses comma as separator
+//   (1) uses comma as separator (or tab, pipe, semicolon etc. when given the --Delim commandline parameter)
 //   (2) has a header row containing unique non-blank column names
 //   (3) has the same number of commas in each row
 //   (4) has cell values, that are valid when trimmed of whitespace
+//   (5) may double-quote any cell (as in RFC 4180), so that it can hold commas, newlines and "" escaped quotes
 //
 // The generated package defines a struct corresponding to one row of the target format.
 // It has functions to read/write files with the target format to/from in-memory arrays.
@@ -29,7 +30,60 @@
 // (Unless the corresponding *Hidden funcs are called).
 // The column names that are written out can be specified with the --HeaderStyle commandline parameter.
 // (1) internal - the golang-legal names of the corresponding in-memory struct members
-// (1) external - taken from the "headerstring" column of the spec file
+// (1) external - taken from the "headerstring" column of the spec file, or the member name where that is blank
+//
+// When reading, each column is located by its member name or headerstring in the header row of the file.
+// So the columns of a file may come in any order, unknown columns are ignored (unless kept, see below), and missing columns are reported.
+// A column is found under its name or headerstring regardless of case and white space, so "TRADE DATE" finds "Trade Date".
+// The headerstring cell may also list aliases after a |, like "Trade Date|TradeDate|TRD_DT": the column is then also found under
+// each alias, the same way, while the first name is the one written. Seen_ holds the header name each column was found under.
+// A column with "optional" in its finaltype (or "optional:VALUE") may be missing from a file: it then loads as its default VALUE
+// (or the zero value), as does a hidden one when hidden columns are not loaded. Any other missing column fails the load with ErrMissingColumns.
+// After Passthrough(true), loading also keeps the columns that are not in the spec: their header names in Overflow_, and their cells
+// in the Overflow_ of each row. The write funcs then write them back, at their positions in the file they were loaded from,
+// with empty cells for rows added after the load. Only those columns keep their positions: the spec columns are written in spec order.
+// A column with "nullable" in its finaltype tells an empty cell from a zero value: its member <Name>_null is set when the cell is empty,
+// the write funcs write an empty cell when it is set, and ClearRow sets it.
+// A column of type decimal(p,s) holds exact numbers of at most p (up to 18) digits, s of them after the point, as a Decimal:
+// its Units, and its Scale, the number of decimals of the cell it was loaded from, so that it is written back with as many decimals,
+// in canonical form: without a + sign or leading zeros, so that "+001.50" is written back as "1.50".
+// Decimal has Add, Sub, Cmp, Rescale (to 0 to 18 decimals) and Float64, and ParseDecimal makes one from text.
+// Add, Sub and Rescale panic if the result does not fit an int64, while Cmp compares any two decimals without rescaling.
+// A column of type yyyy_mm_dd loads from YYYY-MM-DD (with any separator) or yyyymmdd, and is written as yyyymmdd,
+// or as YYYY-MM-DD with "format:YYYY-MM-DD" in its finaltype.
+// A column of type YYYY_MM_DD_HH_MM_SS_mmm_zz is held as its date yyyymmdd, and the members <Name>_hhmmss, <Name>_mmm and <Name>_zz
+// (the zone offset in hours), as genutil parses it. It is written back as YYYY-MM-DD HH:MM:SS.mmm+zz, or as an empty cell
+// when it is cleared (or was loaded from one), and the method <Name>Time() of the row (or, for a header or footer, of the file)
+// returns it as a time.Time.
+// A column of type time(LAYOUT) or time(LAYOUT,TZ) is held as a time.Time, parsed with the golang LAYOUT (or epoch or epochms, for
+// seconds or milliseconds since 1970) in the location TZ, or UTC. It is written back with LAYOUT, or with the layout after "format:",
+// which then comes last in the finaltype, as a layout may hold / and :. Files written in either layout load. Such a column can be
+// indexed, keyed by its instants (in UTC, so that a time in any location finds the rows of the same instant),
+// and an instance variable of a time type can be sorted by, like one of type time.Time.
+// A numeric column with "format:SPEC" in its finaltype is written (by WriteRow, WriteRowHidden, PrintRowSep and SprintRowSep) as SPEC says:
+// [+|(][,][.N][f|e|g], for a sign on positive numbers too or negative numbers in parentheses, commas between thousands,
+// and for float64 only, N decimals in fixed (f, the default), exponent (e) or shortest (g) notation; so "(,.2f" writes -1234.5 as "(1,234.50)".
+// Loading undoes the sign and commas.
+//
+// Load, LoadBuf, Proc and the WriteFile funcs panic on failure. Each has an E variant (LoadE, LoadBufE, ProcE, WriteFileE, ...)
+// that returns an *Error instead, holding the file name, line number, column name and raw cell text of the failure.
+//
+// LoadReader(r, name) loads from any io.Reader (an HTTP body, an archive member, a test fixture) like LoadE loads a file.
+// WriteTo(w) and SortwriteTo(w) write to any io.Writer what WriteFile and SortwriteFile write to a file.
+//
+// Loading normally turns a cell that does not parse (like "N/A" in an int64 column) into the zero value. After Strict(true),
+// Load, LoadBuf and Proc also check every cell against its type and every row against the column count of the header,
+// and collect each failure in Report_ as an *Error that also holds the expected type. PrintReport prints them.
+//
+// Rows that AddRow (or the ProcRowFunc of Proc) refuses are printed as "bad row=". After Rejectfile(name), they are written
+// to that file instead, with the reason appended as an extra column Reason_, so they can be fixed and loaded again:
+// it has the preamble of the file loaded, and its trailer, with rowcount members counting the rejected rows.
+// Malformed rows (wrong column count, or failing the strict checks) then also go to the reject file rather than being loaded.
+//
+// Spec rows with finaltype "header" become file-level members, held in a preamble row, which comes before the row of column names,
+// one cell per header member in spec order. Likewise finaltype "footer" members are held in a trailer row after the last row.
+// Load, LoadBuf and Proc fill these members, and the WriteFile funcs write them. A footer declared "footer:rowcount" holds
+// the number of rows: loading checks it against the rows read (Numread_), and writing fills it in with the rows written.
 //
 // Gencsv can be called in 2 modes
 //   (1) GENCFG: to generate the spec file
@@ -46,15 +100,46 @@
 //   If preceded with "*" BAZ is noted to be the favourite index, i.e, the order to be used when writing out the file in sorted order.
 // For participation in a number of indexes, just concatenate index descriptions.
 //
+// "gencsv --Lint spec.cfg" checks a spec file before any code is generated, and reports each problem as "spec.cfg:LINE: NAME: problem":
+// lines that are not spec rows, names that are not exported golang identifiers or are repeated, unknown types, defaults that do not parse as their type,
+// header names holding a quote, backslash, newline or the field separator,
+// finaltype entries without a :type, bad index descriptions, gaps or clashes in multi-column index positions,
+// indexes on columns of other types than string, int64 and time, and a missing (favourite) index. It exits with status 1 if there is any.
+// GENCSV mode runs the same checks first, and generates nothing if they fail.
+//
+// Given a sample data file instead of a header, "gencsv --Infer sample.csv" scans its first rows (--Rows, default 1000)
+// and proposes a type for each column: int64, float64, bool, yyyymmdd, yyyy_mm_dd or string.
+// String and int64 columns whose values are all distinct are listed as candidate indexes, and the first of them is made the favourite index.
+// Member names are made golang identifiers, and unique by a number, as in A_b and A_b2 for "A-B" and "a.b". A header name that
+// a spec file cannot hold (with a comma, |, quote, backslash or newline) is written with those replaced, under a # comment.
+// The spec file is written to --Ofile, or to stdout.
+//
+// A spec may instead be written in a structured form, as JSON, in a file named *.json (given to --Cfg or --Lint like a spec file).
+// It has explicit sections: "columns" (name, headerstring, aliases, type, and hidden, header, footer, rowcount, optional and default, nullable, format, or extra members),
+// "indexes" (name, the columns in key order, the sep joining them, and whether it is the favourite),
+// "instance" (name, headerstring, type, and sort), and "options" (pkg, caps_pkg, header_style, underscore, delim, module, import_prefix).
+// The options are used where the commandline does not set them. "gencsv --Cfg foo.cfg --Convert foo.json" writes the structured form
+// of a spec file (and "--Convert foo.cfg" of a *.json spec writes it back, with its options as a comment), instead of generating a package.
+//
 // The package file which is created should not be hand edited.
 // Often, you will decide you want change the number or components of the indexes.
 // To do so, just change the spec file, then rerun gencsv.
+// The package file holds a hash of the spec, of the options that shape it and of the templates, so a go:generate line next to the spec, like
+//   //go:generate gencsv --Cfg foo.cfg --Pkg foo --Ofile foo.go --Underscore end
+// keeps them together, and the same command with --Check added exits with status 1 if foo.go is missing or stale:
+// if it differs in any byte from what this gencsv generates, as after a hand edit, or a change of gencsv or of the spec.
 //
 // Conventions:
 // (1) Generate all such packages in subdirs of the "anydset" directory.
 // (2) Add custom functionality in the file <packagename>_more.go in the same directory as the generated file.
 //
-// Gencsv also generates test "main program" and a bash script to run the main program.
+// Gencsv also generates <pkg>_test.go next to the package file (or to --Test, or not at all with --Test none), so go test covers each format.
+// Its tests load a synthetic file with a sample value of its type in each cell, check Numrows_, write it with WriteFile and load it again,
+// expecting the same rows, write them one by one between WriteFileStart and WriteFileEnd and load that file too,
+// find each row with the FindOrNew* and HasMap* funcs of each index, and check that Passthrough keeps an unknown column.
+// By default the generated code is laid out for GOPATH: the package imports "genutil", and the tests import "anydset/<pkg>".
+// With --Module, the package imports "github.com/LDCS/genutil", and the tests import "<ImportPrefix>/<pkg>",
+// where --ImportPrefix defaults to the module path.
 // If "--Underscore" commandline parameter is "end", gencsv appends an underscore to column members.
 //
 // Instance variables that are of foo.Bar type will result in an import of "foo" in the generated code
@@ -62,84 +147,70 @@
 // Gencsv generates code to store multiple hcsv instances in a map (PointerMap)
 // If an instance variable's config has "sort" in its hasindex field, code is generated to sort the PointerMap by that variable
 // (If the type is time.Time, it is sorted by UnixNano())
+//
+// Gencsv is also a library, so that build tools can generate many formats in one process, without running the command:
+// LoadSpecFile (or LoadSpec, or NewSpec and AddRow) makes a Spec, and Generate(spec, options) returns the source of its package.
+// GenerateTest returns the source of <pkg>_test.go, and Infer proposes a spec file from sample data.
+// The command is built from cmd/gencsv (go install github.com/LDCS/gencsv/cmd/gencsv), and just calls these.
+//
+// The generated files are written by the text/template files in templates/, and gofmt'd (with go/format) before they are written.
+// Each column type is an entry in colTypes (gencsv_types.go), that says how a column of that type is held, loaded, written and checked.
+// So adding a column type only needs a new entry there (with a Make func, if it takes parameters, like decimal(p,s)).
+// The go tests of gencsv itself (gencsv_test.go) cover Lint, Infer, the structured spec, Check and the column types, and generate the _demo packages, then vet and test them.
 
-package main
+// Package gencsv generates the package for an hcsv format from its spec, as described above
+package gencsv
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"github.com/LDCS/genutil"
-	"github.com/LDCS/sflag"
 	"io"
-	"os"
 	"sort"
-	"strconv"
 	"strings"
 )
 
-var (
-	opt = struct {
-		Usage       string "generate bespoke package for an hcsv format"
-		Pkg         string "Name of the hcsv format, to be used as its package name"
-		CapsPkg     string "If left empty, it will be set ToUpper(Pkg)				|"
-		Cfg         string "Valid hcsv file holding spec of the target hcsv format	|"
-		Ofile       string "Filename for the generated package file"
-		TestMain    string "Filename of main program for testing					| ./TestMain.go"
-		TestBash    string "Filename for the bash script for testing				| ./TestMain.bash"
-		HeaderStyle string "Member variable names should be internal or external	| internal"
-		Underscore  string "Members should have (no or end) underscore				| no"
-		Gopath      string "GOPATH for the test program"
-		Goroot      string "GOROOT for the test program"
-		Args        []string
-	}{}
-	capsName         = ""
-	endUnder         = ""
-	needStrConv      = false
-	needBytes        = false
-	needDropRowInt64 = false
-)
-
-func parseArgs() bool {
-	sflag.Parse(&opt)
-	if len(opt.Cfg) < 1 {
-		return false
-	} // Assume it is being called in gencfg mode
+// Options are the settings of a generated package, other than its spec
+type Options struct {
+	Pkg          string    `json:"pkg,omitempty"`           // Name of the hcsv format, to be used as its package name
+	CapsPkg      string    `json:"caps_pkg,omitempty"`      // If left empty, it will be set ToUpper(Pkg)
+	HeaderStyle  string    `json:"header_style,omitempty"`  // Member variable names should be internal (the default) or external
+	Underscore   string    `json:"underscore,omitempty"`    // Members should have no (the default) or end underscore
+	Delim        string    `json:"delim,omitempty"`         // Field separator of the target format: comma (the default), tab, pipe, semicolon or a single character
+	Module       string    `json:"module,omitempty"`        // If set, the module the generated packages are in, and they import github.com/LDCS/genutil
+	ImportPrefix string    `json:"import_prefix,omitempty"` // Import path of the directory holding the package directory, if not Module (or anydset without Module)
+	Log          io.Writer `json:"-"`                       // If set, the indexes made from the spec are described to it
+}
 
-	if len(opt.Args) < 0 {
-		fmt.Println("Usage: go-gencsv filename ofile")
-		panic("filename not specified")
-	}
-	capsName = opt.CapsPkg
-	if len(capsName) < 1 {
-		capsName = strings.ToUpper(opt.Pkg)
-	}
-	if len(opt.Pkg) < 1 {
-		panic("Pkg must be specified\n" + opt.Usage)
-	}
-	switch opt.HeaderStyle {
-	case "internal", "external":
-	default:
-		panic("HeaderStyle must be \"internal\" or \"external\", not " + opt.HeaderStyle + "\n" + opt.Usage)
-	}
-	switch opt.Underscore {
-	case "end":
-		endUnder = "_"
-	case "no":
-		endUnder = ""
-	default:
-		panic("Underscore must be one of \"end\" or \"no\", not " + opt.Underscore + "\n" + opt.Usage)
-	}
-	return true
+// parseDelim returns the field separator named _delim, escaped for use inside generated string and rune literals, and as a byte
+func parseDelim(_delim string) (string, byte, error) {
+	switch _delim {
+	case "comma", "":
+		return ",", ',', nil
+	case "tab", "\t":
+		return "\\t", '\t', nil
+	case "pipe":
+		return "|", '|', nil
+	case "semicolon":
+		return ";", ';', nil
+	}
+	if len(_delim) != 1 || strings.ContainsAny(_delim, "\"'\\\r\n ") {
+		return "", 0, errors.New("Delim must be one of \"comma\", \"tab\", \"pipe\", \"semicolon\" or a single character other than quote, backslash, space or newline, not " + _delim)
+	}
+	return _delim, _delim[0], nil
 }
 
 type xatt struct {
-	xname string
-	xtype string
+	Xname string
+	Xtype string
 }
 
 // GENCSVElem describes a row of the spec file, in addition to some non-spec helper variables
 type GENCSVElem struct {
 	Name         string
 	Headerstring string
+	Aliases      []string // further header names the column is accepted under, after a | in the headerstring cell
 	Type         string
 	OutType      string
 	Hasindex     string
@@ -148,11 +219,12 @@ type GENCSVElem struct {
 	Header       bool
 	Footer       bool
 	FooterCount  bool
-	LastShown    bool
-	Last         bool
-	FirstShown   bool
+	Optional     bool   // the column may be missing from a file, and then loads as Default
+	Default      string // the cell text an optional column loads as when it is missing
+	Nullable     bool   // an empty cell loads as null, held in the member <Name>_null, rather than as the zero value
+	Numfmt       string // how a numeric column is written, as parsed by parseNumFormat, or the layout a time column is written with
 	Xarr         []xatt
-	Yarr         []xatt
+	Line         int // of the spec file, or 0 if added by AddRow
 }
 
 // GENCSVElemPtr is shorthand
@@ -163,112 +235,74 @@ type GENCSVElemPtrSlice []GENCSVElemPtr
 
 type bslice []byte
 
-var comma byte = ','
-var fslash byte = '/'
-var arr GENCSVElemPtrSlice
-var yarr GENCSVElemPtrSlice
+// specHeader is the first line of a spec file, naming its cells
+const specHeader = "name,headerstring,type,hasindex,finaltype"
 
-func loadElem(_bsl bslice) (row *GENCSVElem) {
-	lenslice, ii, jj, print := len(_bsl), 0, 0, false
-	row = new(GENCSVElem)
-	row.Hidden = false
-	row.Header = false
-	row.Footer = false
-	row.FooterCount = false
-	row.LastShown = false
-	row.Last = false
-	row.FirstShown = false
-	for ii = jj; jj < lenslice; jj++ {
-		if _bsl[jj] == comma {
-			row.Name = strings.TrimSpace(string(_bsl[ii:jj]))
-			if print {
-				fmt.Println("Name=", row.Name)
-			}
-			jj++
-			break
+// Spec holds the spec rows of one hcsv format, as read by LoadSpec or added by AddRow
+type Spec struct {
+	Cols GENCSVElemPtrSlice // the columns, including hidden, header and footer ones, in spec order
+	Inst GENCSVElemPtrSlice // the instance variables
+	Bad  SpecErrors         // the lines LoadSpec could not read as spec rows
+
+	Options Options // the options section of a structured spec, read by LoadSpecJSON
+
+	Numread, Numbad, Numempty, Numcomment int // what LoadSpec made of the lines of the spec file
+}
+
+// NewSpec returns an empty spec, for AddRow
+func NewSpec() *Spec {
+	return new(Spec)
+}
+
+// AddRow adds the spec row with the given cells, as they would be written in a spec file
+func (self *Spec) AddRow(_name, _headerstring, _type, _hasindex, _finaltype string) (*GENCSVElem, error) {
+	row := new(GENCSVElem)
+	row.Name = strings.TrimSpace(_name)
+	names := strings.Split(_headerstring, "|")
+	row.Headerstring = strings.TrimSpace(names[0])
+	for _, alias := range names[1:] {
+		if alias = strings.TrimSpace(alias); len(alias) > 0 {
+			row.Aliases = append(row.Aliases, alias)
 		}
 	}
-	for ii = jj; jj < lenslice; jj++ {
-		if _bsl[jj] == comma {
-			row.Headerstring = strings.TrimSpace(string(_bsl[ii:jj]))
-			if print {
-				fmt.Println("Headerstring=", row.Headerstring)
-			}
-			jj++
-			break
-		}
+	row.Type = strings.TrimSpace(_type)
+	if row.Type == "" {
+		row.Type = "string"
 	}
-	for ii = jj; jj < lenslice; jj++ {
-		if _bsl[jj] == comma {
-			row.Type = strings.TrimSpace(string(_bsl[ii:jj]))
-			if row.Type == "" {
-				row.Type = "string"
-			}
-			if print {
-				fmt.Println("Type=", row.Type)
-			}
-			jj++
-			break
-		}
+	row.Hasindex = strings.TrimSpace(_hasindex)
+	if row.Hasindex == "" {
+		row.Hasindex = "noindex"
 	}
-	for ii = jj; jj < lenslice; jj++ {
-		if _bsl[jj] == comma {
-			row.Hasindex = strings.TrimSpace(string(_bsl[ii:jj]))
-			if row.Hasindex == "" {
-				row.Hasindex = "noindex"
-			}
-			if print {
-				fmt.Println("Hasindex=", row.Hasindex)
-			}
-			jj++
-			break
-		}
+	row.Finaltype = strings.TrimSpace(_finaltype)
+	if row.Finaltype == "" {
+		row.Finaltype = "none"
 	}
-	for ii = jj; jj < lenslice; jj++ {
-		if jj+1 == lenslice {
-			if _bsl[jj-1] == '' {
-				jj--
-			}
-			row.Finaltype = strings.TrimSpace(string(_bsl[ii:jj]))
-			if row.Finaltype == "" {
-				row.Finaltype = "none"
-			}
-			if print {
-				fmt.Println("Finaltype=", row.Finaltype)
-			}
-			jj++
-			break
-		}
+	if row.Name == "" {
+		return nil, &SpecError{Err: "spec row has no name"}
+	}
+	if len(row.Aliases) > 0 && row.Finaltype == "instance" {
+		return nil, &SpecError{Name: row.Name, Err: "instance variables have no header, so cannot have aliases"}
 	}
 
-	mightNeedBytes := false
 	row.OutType = row.Type
-	switch row.Type {
-	case "int64", "bool":
-		needStrConv = true
-	case "float64":
-		needStrConv = true
-		mightNeedBytes = true
-	case "yyyymmdd":
-		needStrConv = true
-		row.OutType = "int64"
-	case "yyyy_mm_dd":
-		needStrConv = true
-		row.OutType = "int64"
-		mightNeedBytes = true
-	case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-		needStrConv = true
-		row.OutType = "int64"
-		mightNeedBytes = true
+	if ct := lookupType(row.Type); ct != nil {
+		row.Type, row.OutType = ct.Name, ct.OutType // the name of a type made from parameters is normalized, as in decimal(18,4)
 	}
 
+	finaltype := row.Finaltype
+	if ct := lookupType(row.Type); ct != nil && ct.Time != nil { // the layout of a time column may hold / and :, so its format comes last, and takes the rest
+		if at := strings.Index("/"+finaltype, "/format:"); at >= 0 {
+			row.Numfmt = strings.TrimSpace(finaltype[at+len("format:"):])
+			finaltype = strings.TrimSuffix(finaltype[:at], "/")
+		}
+	}
 	perinstance := false
-	switch row.Finaltype {
+	switch finaltype {
 	case "", "none":
 	case "instance":
 		perinstance = true
 	default:
-		pairs := strings.Split(row.Finaltype, "/")
+		pairs := strings.Split(finaltype, "/")
 		for _, vv := range pairs {
 			kvs := strings.Split(vv, ":")
 			if (len(kvs) == 1) && (kvs[0] == "hidden") {
@@ -278,198 +312,153 @@ func loadElem(_bsl bslice) (row *GENCSVElem) {
 			if (len(kvs) >= 1) && (kvs[0] == "header") {
 				row.Hidden = true
 				row.Header = true
-				mightNeedBytes = false
+				continue
+			}
+			if (len(kvs) == 1) && (kvs[0] == "nullable") {
+				row.Nullable = true
+				continue
+			}
+			if kvs[0] == "format" && len(kvs) == 2 {
+				row.Numfmt = strings.TrimSpace(kvs[1])
+				continue
+			}
+			if kvs[0] == "optional" {
+				row.Optional = true
+				row.Default = strings.TrimSpace(strings.Join(kvs[1:], ":"))
 				continue
 			}
 			if (len(kvs) >= 1) && (kvs[0] == "footer") {
 				row.Hidden = true
 				row.Footer = true
-				mightNeedBytes = false
 				if (len(kvs) > 1) && (kvs[1] == "rowcount") {
 					row.FooterCount = true
 				}
 				continue
 			}
+			if len(kvs) < 2 || len(strings.TrimSpace(kvs[1])) < 1 {
+				return nil, &SpecError{Name: row.Name, Err: fmt.Sprintf("finaltype %q is not hidden, header, footer, optional, nullable, format, instance or name:type", vv)}
+			}
 			xrow := new(xatt)
-			xrow.xname = row.Name + "_" + strings.Trim(kvs[0], "\t\n\r ") + "_"
-			xrow.xtype = strings.Trim(kvs[1], "\t\n\r ")
+			xrow.Xname = row.Name + "_" + strings.Trim(kvs[0], "\t\n\r ") + "_"
+			xrow.Xtype = strings.Trim(kvs[1], "\t\n\r ")
 			row.Xarr = append(row.Xarr, *xrow)
 		}
 	}
-	if mightNeedBytes {
-		needBytes = true
-	}
 
 	switch perinstance {
 	case true:
-		_, ok := yAddRow(row)
-		if !ok {
-			fmt.Println("gencsv bad per-instance variable row=", string(_bsl))
-		}
+		self.Inst = append(self.Inst, row)
 	case false:
-		_, ok := addRow(row)
-		if !ok {
-			fmt.Println("gencsv bad row=", string(_bsl))
-		}
+		self.Cols = append(self.Cols, row)
 	}
-	return row
+	return row, nil
 }
 
-func addRow(_row *GENCSVElem) (*GENCSVElem, bool) {
-	ok := true
-	arr = append(arr, _row)
-	return _row, ok
+// headerCell returns the headerstring cell of the spec row _row: its Headerstring, followed by each of its Aliases after a |
+func headerCell(_row *GENCSVElem) string {
+	return strings.Join(append([]string{_row.Headerstring}, _row.Aliases...), "|")
 }
 
-func yAddRow(_row *GENCSVElem) (*GENCSVElem, bool) {
-	ok := true
-	yarr = append(yarr, _row)
-	return _row, ok
+// splitSpecRow splits the line _line of a spec file into its cells, at the commas that are not inside parentheses,
+// so that types like decimal(18,4) stay whole. The last of the 5 cells takes the rest of the line
+func splitSpecRow(_line string) []string {
+	cells, depth, start := []string{}, 0, 0
+	for ii := 0; ii < len(_line) && len(cells) < 4; ii++ {
+		switch _line[ii] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth <= 0 {
+				cells = append(cells, _line[start:ii])
+				start = ii + 1
+			}
+		}
+	}
+	return append(cells, _line[start:])
 }
 
-func loadSpec(_fname string) {
-	rr := genutil.OpenAny(_fname)
-	numread, numbad, numempty, numcomment := 0, 0, 0, 0
-	for first := true; ; first = false {
+// loadElem adds the spec row held in the line _bsl of a spec file
+func (self *Spec) loadElem(_bsl bslice) (*GENCSVElem, error) {
+	cells := splitSpecRow(strings.TrimRight(string(_bsl), "\r\n"))
+	for len(cells) < 5 {
+		cells = append(cells, "")
+	}
+	return self.AddRow(cells[0], cells[1], cells[2], cells[3], cells[4])
+}
+
+// LoadSpec reads a spec file from _rr. The lines that are not spec rows are counted in Numbad, and kept in Bad for Lint
+func LoadSpec(_rr io.Reader) (*Spec, error) {
+	self := NewSpec()
+	rr := bufio.NewReader(_rr)
+	bad := func(_line int, _err string) {
+		self.Numbad++
+		self.Bad = append(self.Bad, &SpecError{Line: _line, Err: _err})
+	}
+	line, first := 0, true // first until a line other than an empty or comment one, as WriteCSV puts the options before the header
+	for {
 		bsl, err := rr.ReadSlice('\n')
 		if err != nil && err != io.EOF {
-			panic(err)
+			return nil, err
 		}
-		if err == io.EOF {
+		if err == io.EOF && len(bsl) == 0 {
 			break
-		}
-		if len(bsl) < 2 {
-			numempty++
+		} // else a last line without newline is read too
+		line++
+		if len(strings.TrimSpace(string(bsl))) < 1 {
+			self.Numempty++
 			continue
 		}
 		if bsl[0] == '#' {
-			numcomment++
-			continue
-		}
-		if len(bsl) < 3 {
-			numbad++
+			self.Numcomment++
 			continue
 		}
-		if first {
-			matchline := "name,headerstring,type,hasindex,finaltype"
-			if string(bsl)[:len(matchline)] == matchline {
-			}
-		}
-		if (bsl[0] == 'n') && (bsl[1] == 'a') && (bsl[2] == 'm') && (bsl[3] == 'e') && (bsl[4] == ',') {
+		if strings.HasPrefix(string(bsl), "name,") {
 			if !first {
-				numbad++
+				bad(line, "repeated header line")
 			}
+			first = false
 			continue
 		}
-		if !first {
-			loadElem(bsl)
-			numread++
-		}
-	}
-
-	for ii := len(arr) - 1; ii >= 0; ii-- {
-		if !arr[ii].Hidden {
-			arr[ii].LastShown = true
-			break
+		if first {
+			bad(line, "first line is not the header "+specHeader+", and is skipped")
+			first = false
+			continue
 		}
-	} // Note that .Footer implies .Hidden, so Footer will not be shown
-	for ii := len(arr) - 1; ii >= 0; ii-- {
-		if !arr[ii].Footer {
-			arr[ii].Last = true
-			break
+		if ncells := len(splitSpecRow(string(bsl))); ncells < 5 {
+			bad(line, fmt.Sprintf("has %d cells, want 5: %s", ncells, specHeader))
+			continue
 		}
-	} // was : arr[len(arr)-1 ].Last = true;
-	for ii := 0; ii < len(arr); ii++ {
-		if !arr[ii].Hidden {
-			arr[ii].FirstShown = true
-			break
+		row, err := self.loadElem(bsl)
+		if err != nil {
+			se := err.(*SpecError)
+			se.Line = line
+			self.Numbad++
+			self.Bad = append(self.Bad, se)
+			continue
 		}
+		row.Line = line
+		self.Numread++
 	}
-
-	fmt.Println(" numread=", numread, "numbad=", numbad, "numempty=", numempty, "numcomment=", numcomment)
-}
-
-func writeTest(_fo io.Writer) {
-	io.WriteString(_fo, "// Package main is Machine Generated - By gencsv.go - For testing "+opt.Pkg+" - Do not edit\n")
-	io.WriteString(_fo, "package main\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "import (\n")
-	io.WriteString(_fo, "    \"fmt\"\n")
-	io.WriteString(_fo, "    \"anydset/"+opt.Pkg+"\"\n")
-	io.WriteString(_fo, ")\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "var (\n")
-	io.WriteString(_fo, "    "+opt.Pkg+"1 	*"+opt.Pkg+"."+capsName+"\n")
-	io.WriteString(_fo, ")\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "func main() {\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "    "+opt.Pkg+"1	= "+opt.Pkg+".New"+capsName+"(true)\n")
-	io.WriteString(_fo, "    "+opt.Pkg+"1.Load(\"samples/"+opt.Pkg+".csv\")\n")
-	io.WriteString(_fo, "    fmt.Println(\"loaded\")\n")
-	io.WriteString(_fo, "    "+opt.Pkg+"1.Proc(\"samples/"+opt.Pkg+".csv\", "+opt.Pkg+".ProcFuncSample)\n")
-	io.WriteString(_fo, "    fmt.Println(\"processed\")\n")
-	io.WriteString(_fo, "    "+opt.Pkg+"1.WriteFile(\"out."+opt.Pkg+".csv\")\n")
-	io.WriteString(_fo, "}\n")
-}
-
-func writeDoit(_fo io.Writer) {
-	io.WriteString(_fo, "#!/bin/bash\n")
-	io.WriteString(_fo, "# Machine Generated - By gencsv.go - Do not edit\n")
-	if opt.Goroot != "" && opt.Gopath != "" {
-		io.WriteString(_fo, "export GOROOT="+opt.Goroot+"\n")
-		io.WriteString(_fo, "export GOPATH="+opt.Gopath+"\n")
-	}
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "$GOROOT/bin/go build test_"+opt.Pkg+".go\n")
-	io.WriteString(_fo, "if [ \"$?\" = \"0\" ]; then\n")
-	io.WriteString(_fo, "  echo ./test_"+opt.Pkg+"\n")
-	io.WriteString(_fo, "       ./test_"+opt.Pkg+"\n")
-	io.WriteString(_fo, "else\n")
-	io.WriteString(_fo, "      echo \"failed "+opt.Pkg+"\"\n")
-	io.WriteString(_fo, "fi\n")
+	return self, nil
 }
 
-func writePre(_fo io.Writer) {
-	io.WriteString(_fo, "// Package "+opt.Pkg+" was Machine Generated - By gencsv.go - Do not edit - Put your handcrafted code in "+opt.Pkg+"_more.go\n")
-	io.WriteString(_fo, "package "+opt.Pkg+"\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "import (\n")
-	io.WriteString(_fo, "	\"fmt\"\n")
-	io.WriteString(_fo, "	\"io\"\n")
-	io.WriteString(_fo, "	\"log\"\n")
-	io.WriteString(_fo, "	\"sort\"\n")
-	io.WriteString(_fo, "	\"genutil\"\n")
-	if needStrConv {
-		io.WriteString(_fo, "	\"strconv\"\n")
+// LoadSpecFile reads the spec file _fname, which is in the structured form if it is named *.json
+func LoadSpecFile(_fname string) (*Spec, error) {
+	rr := genutil.OpenAny(_fname)
+	if rr == nil {
+		return nil, errors.New("cannot open spec file " + _fname)
 	}
-	if needBytes {
-		io.WriteString(_fo, "	\"bytes\"\n")
+	load := LoadSpec
+	if strings.HasSuffix(strings.TrimSuffix(_fname, ".gz"), ".json") {
+		load = LoadSpecJSON
 	}
-	io.WriteString(_fo, "	\"strings\"\n")
-
-	// Now set up imports for packages used by instance variables
-	if true {
-		ydone := map[string]bool{}
-		for _, yrow := range yarr {
-			switch yrow.Type {
-			case "string", "int", "int64", "float64":
-			default:
-				if strings.Contains(yrow.Type, ".") {
-					ypkg := yrow.Type[:strings.Index(yrow.Type, ".")]
-					if !ydone[ypkg] {
-						ydone[ypkg] = true
-						io.WriteString(_fo, "	\""+ypkg+"\"\n")
-					}
-				}
-			}
-		}
+	spec, err := load(rr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", _fname, err)
 	}
-	io.WriteString(_fo, "        )\n\n")
-
-	io.WriteString(_fo, "type bslice []byte\n")
-	io.WriteString(_fo, "var comma  byte	= ','\n")
-	io.WriteString(_fo, "\n")
+	return spec, nil
 }
 
 type indexMapElem struct {
@@ -477,21 +466,36 @@ type indexMapElem struct {
 	Rows []string
 	Type string
 	Sep  string
+	Line int // of the first spec row in the index
 }
 type indexMapElemPtr *indexMapElem
 type indexMapType map[string]indexMapElemPtr
 
-var indexMap indexMapType
-
-var favIM *indexMapElem // Will be the index used in Sortedwrite* funcs
-var sortedIndexKeys []string
-var sortedIndexVals []indexMapElemPtr
+// keyType returns the type of the key of an index on the column _row alone: its type, or time.Time for a time column
+func keyType(_row *GENCSVElem) string {
+	if ct := lookupType(_row.Type); ct != nil && ct.Time != nil {
+		return ct.OutType
+	}
+	return _row.Type
+}
 
-func makeIndexes() {
+// makeIndexes returns the indexes described by the hasindex cells of the columns _cols, sorted by name,
+// and the favourite one, to be used in Sortedwrite* funcs. What it makes is described to _log.
+// The problems it finds are returned as SpecErrors
+func makeIndexes(_cols GENCSVElemPtrSlice, _log io.Writer) ([]indexMapElemPtr, *indexMapElem, error) {
 	favName := "" // This will be used in Sortwrite calls
-	indexMap = make(map[string]indexMapElemPtr)
-	for _, row := range arr {
+	indexMap := make(indexMapType)
+	var errs SpecErrors
+	bad := func(_row *GENCSVElem, _err string) {
+		errs = append(errs, &SpecError{Line: _row.Line, Name: _row.Name, Err: _err})
+	}
+	for _, row := range _cols {
 		if row.Header || row.Footer {
+			switch row.Hasindex {
+			case "noindex", "none", "":
+			default:
+				bad(row, "header and footer members cannot be indexed")
+			}
 			continue
 		}
 		switch row.Hasindex {
@@ -501,15 +505,13 @@ func makeIndexes() {
 			im.Name = row.Name
 			im.Sep = ":"
 			im.Rows = append(im.Rows, row.Name)
-			im.Type = row.Type
-			if im.Type == "int64" {
-				needDropRowInt64 = true
-			}
+			im.Type = keyType(row)
+			im.Line = row.Line
 			indexMap[row.Name] = im
 			if (row.Hasindex == "*index") && (favName == "") {
 				favName = row.Name
 			}
-			fmt.Println(" Creating simple index im.Name=", im.Name, " type=", im.Type, " on column=", im.Name)
+			fmt.Fprintln(_log, " Creating simple index im.Name=", im.Name, " type=", im.Type, " on column=", im.Name)
 		default:
 			ix := row.Hasindex
 			favthis, favnext := false, false
@@ -519,10 +521,11 @@ func makeIndexes() {
 				}
 				ix = ix[1:]
 			} else if !strings.HasPrefix(row.Hasindex, "index(") {
-				panic("makeIndexes: bad index column in row=" + row.Name)
+				bad(row, "hasindex "+row.Hasindex+" is not noindex, index, *index or index(NAME=POS=SEP)...")
+				continue
 			}
 			parts := strings.Split(ix[6:], "index(")
-			fmt.Println("makeIndexes hasindex=", row.Hasindex)
+			fmt.Fprintln(_log, "makeIndexes hasindex=", row.Hasindex)
 			for ipi, ip := range parts {
 				if strings.HasSuffix(ip, "*") {
 					if favName == "" {
@@ -532,9 +535,21 @@ func makeIndexes() {
 					}
 					ip = ip[:len(ip)-1] // discard the * (favindex marker)
 				}
+				if !strings.HasSuffix(ip, ")") {
+					bad(row, "index("+ip+" is missing its closing )")
+					continue
+				}
 				parts2 := strings.SplitN(ip[:len(ip)-1], "=", 3) // drop the trailing ")" before split
-				iname, inum := parts2[0], genutil.ToInt(parts2[1], 0)
-				fmt.Println("    iname=", iname, "  inum=", inum)
+				if len(parts2) < 2 || len(parts2[0]) < 1 {
+					bad(row, "index("+ip+" is not index(NAME=POS=SEP)")
+					continue
+				}
+				iname, inum := parts2[0], genutil.ToInt(parts2[1], -1)
+				if inum < 0 || inum >= 1024 {
+					bad(row, "index("+ip+" has position "+parts2[1]+", want 0, 1, 2...")
+					continue
+				}
+				fmt.Fprintln(_log, "    iname=", iname, "  inum=", inum)
 				im, ok := indexMap[iname]
 				if favName == "" {
 					if favthis {
@@ -546,20 +561,23 @@ func makeIndexes() {
 				switch ok {
 				case true: // multipart index, seen a part before
 					if int(inum) >= len(im.Rows) {
-						fmt.Println(" Appending to found index im.Name=", im.Name, " which pre has len ", len(im.Rows), " sep", im.Sep)
-						im.Rows = im.Rows[:int(inum)+1]
+						fmt.Fprintln(_log, " Appending to found index im.Name=", im.Name, " which pre has len ", len(im.Rows), " sep", im.Sep)
+						for int(inum) >= len(im.Rows) {
+							im.Rows = append(im.Rows, "")
+						}
+					}
+					if len(im.Rows[inum]) > 0 {
+						bad(row, fmt.Sprintf("index %s has both %s and %s at position %d", iname, im.Rows[inum], row.Name, inum))
+						continue
 					}
 					im.Rows[inum] = row.Name
 					switch ipi {
 					case 0:
-						im.Type = row.Type // singlepart keys can be part[0]
+						im.Type = keyType(row) // singlepart keys can be part[0]
 					default:
 						im.Type = "string" // force multipart into string keys
 					}
-					if im.Type == "int64" {
-						needDropRowInt64 = true
-					}
-					fmt.Println(" Appending to found index im.Name=", im.Name, " which now has len ", len(im.Rows), " sep", im.Sep)
+					fmt.Fprintln(_log, " Appending to found index im.Name=", im.Name, " which now has len ", len(im.Rows), " sep", im.Sep)
 
 				default: // not seen this index before
 					im = new(indexMapElem)
@@ -569,1222 +587,79 @@ func makeIndexes() {
 					}
 					im.Rows = make([]string, inum+1, 1024)
 					im.Rows[inum] = row.Name
+					im.Line = row.Line
 					switch len(parts2) {
 					case 0:
-						im.Type = row.Type // singlepart keys can be part[0]
+						im.Type = keyType(row) // singlepart keys can be part[0]
 					default:
 						im.Type = "string" // force multipart into string keys
 					}
-					if im.Type == "int64" {
-						needDropRowInt64 = true
-					}
 					indexMap[iname] = im
-					fmt.Println(" Creating unfound index im.Name=", im.Name, " which now has len ", len(im.Rows), " sep", im.Sep, "from parts=", parts2)
+					fmt.Fprintln(_log, " Creating unfound index im.Name=", im.Name, " which now has len ", len(im.Rows), " sep", im.Sep, "from parts=", parts2)
 				}
 			}
 		}
 	}
 
-	sortedIndexKeys = make([]string, len(indexMap))
-	ii := 0
+	sortedIndexKeys := make([]string, 0, len(indexMap))
 	for kk := range indexMap {
-		sortedIndexKeys[ii] = kk
-		ii++
+		sortedIndexKeys = append(sortedIndexKeys, kk)
 	}
 	sort.Strings(sortedIndexKeys)
-	sortedIndexVals = make([]indexMapElemPtr, len(sortedIndexKeys))
-	ii = 0
+	sortedIndexVals := make([]indexMapElemPtr, len(sortedIndexKeys))
 	for ii, kk := range sortedIndexKeys {
 		sortedIndexVals[ii] = indexMap[kk]
 	}
 
-	if len(sortedIndexVals) <= 0 {
-		panic("gencsv.makeIndexes: PanicExit - Please define atleast one index on " + capsName + "\n")
-	}
-
-	if favName == "" {
-		panic("gencsv.makeIndexes: PanicExit - Please tag one index as favourite (to be used in Sortwrite*\n")
-		// favName = sortedIndexVals[0].Name
-	}
-	favIM, _ = indexMap[favName]
-}
-
-func writeStruct(_fo io.Writer) {
-	makeIndexes()
-
-	io.WriteString(_fo, "// "+capsName+"Elem describes one row\n")
-	io.WriteString(_fo, "//")
-	for _, row := range arr {
-		if row.Header {
-			io.WriteString(_fo, row.Name+",")
-		}
-	}
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "//")
-	for _, row := range arr {
-		if !(row.Header || row.Footer) {
-			io.WriteString(_fo, row.Name+",")
-		}
-	}
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "//")
-	for _, row := range arr {
-		if row.Footer {
-			io.WriteString(_fo, row.Name+",")
-		}
-	}
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "type "+capsName+"Elem struct {\n")
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
+	types := map[string]*GENCSVElem{}
+	for _, row := range _cols {
+		if _, ok := types[row.Name]; !ok {
+			types[row.Name] = row
 		}
-		io.WriteString(_fo, "	"+row.Name+endUnder+"	"+row.OutType+"\n")
-		for _, xrow := range row.Xarr {
-			io.WriteString(_fo, "		"+xrow.xname+"_	"+xrow.xtype+"\n")
-		}
-		switch row.Type {
-		case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-			io.WriteString(_fo, "	"+row.Name+"_hhmmss"+endUnder+"	int64\n")
-			io.WriteString(_fo, "	"+row.Name+"_mmm"+endUnder+"	int64\n")
-			io.WriteString(_fo, "	"+row.Name+"_zz"+endUnder+"	int64\n")
-		}
-	}
-	io.WriteString(_fo, "}\n\n")
-
-	io.WriteString(_fo, "//"+capsName+"ElemPtr is shorthand\n")
-	io.WriteString(_fo, "type	"+capsName+"ElemPtr *"+capsName+"Elem\n")
-	io.WriteString(_fo, "//"+capsName+"ElemPtrSlice is shorthand\n")
-	io.WriteString(_fo, "type	"+capsName+"ElemPtrSlice []"+capsName+"ElemPtr\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "//"+capsName+" holds the in-memory representation of an instance this target file format\n")
-	io.WriteString(_fo, "type "+capsName+" struct{\n")
-	io.WriteString(_fo, "	Verbose_ bool\n")
-	io.WriteString(_fo, "	Silent_ bool\n")
-	io.WriteString(_fo, "	Loadhidden_ bool\n")
-	io.WriteString(_fo, "	Nullkey_ bool\n")
-	io.WriteString(_fo, "	Numread_ int\n")
-	io.WriteString(_fo, "	Numrows_ int\n")
-	io.WriteString(_fo, "	LoadedFilename_ string\n")
-
-	// perinstance variables
-	for _, row := range yarr {
-		io.WriteString(_fo, "	"+row.Name+"_	"+row.OutType+"\n")
 	}
-
-	// non-perinstance variables
-	for _, row := range arr {
-		if !(row.Header || row.Footer) {
-			continue
-		}
-		comment := ""
-		switch {
-		case row.Header:
-			comment = "	// header"
-		case row.Footer:
-			comment = "	// footer"
-		}
-		io.WriteString(_fo, "	"+row.Name+endUnder+"	"+row.OutType+comment+"\n")
-		for _, xrow := range row.Xarr {
-			io.WriteString(_fo, "		"+xrow.xname+"_	"+xrow.xtype+"\n")
-		}
-		switch row.Type {
-		case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-			io.WriteString(_fo, "	"+row.Name+"_hhmmss"+endUnder+"	int64\n")
-			io.WriteString(_fo, "	"+row.Name+"_mmm"+endUnder+"	int64\n")
-			io.WriteString(_fo, "	"+row.Name+"_zz"+endUnder+"	int64\n")
-		}
-	}
-	for _, row := range sortedIndexVals {
-		io.WriteString(_fo, " Map"+row.Name+"2"+capsName+" map["+row.Type+"]"+capsName+"ElemPtrSlice\n")
-	}
-	io.WriteString(_fo, " }\n")
-	io.WriteString(_fo, "\n") //
-	// ========================================================
-	io.WriteString(_fo, "// New"+capsName+" instantiates an empty instance of target file format"+capsName+"\n")
-	io.WriteString(_fo, "func New"+capsName+"(_verbose bool) *"+capsName+" {\n")
-	io.WriteString(_fo, "	self	:= new("+capsName+")\n")
-	io.WriteString(_fo, "	self.Verbose_    	      = _verbose\n")
-	io.WriteString(_fo, "	self.Silent_    	      = false\n")
-	io.WriteString(_fo, "	self.Loadhidden_   	      = false\n")
-	io.WriteString(_fo, "	self.Nullkey_    	      = true\n")
-	for _, row := range sortedIndexVals {
-		io.WriteString(_fo, "	self.Map"+row.Name+"2"+capsName+"		= make(map["+row.Type+"]"+capsName+"ElemPtrSlice)\n")
-	}
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// Nil returns a nil pointer to "+capsName+"\n")
-	io.WriteString(_fo, "func Nil() *"+capsName+" {\n")
-	io.WriteString(_fo, "	return (*"+capsName+")(nil)\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// NilElemPtr returns a nil pointer to a row of "+capsName+"\n")
-	io.WriteString(_fo, "func NilElemPtr() *"+capsName+"Elem {\n")
-	io.WriteString(_fo, "	return (*"+capsName+"Elem)(nil)\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// Arr returns an array of rows of "+capsName+"\n")
-	io.WriteString(_fo, "func Arr(_len int) []*"+capsName+"{\n")
-	io.WriteString(_fo, "	arr := make([]*"+capsName+", _len)\n")
-	io.WriteString(_fo, "	return arr\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// Silent turns off verbosity when subsequently processing this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Silent() *"+capsName+" {\n")
-	io.WriteString(_fo, "	self.Silent_    	      = true\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "// Verbose sets verbosity when subsequently processing this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Verbose(_verbose bool) *"+capsName+" {\n")
-	io.WriteString(_fo, "	self.Silent_    	      = !_verbose\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "// Loadhidden sets the subsequent load to expect and load hidden columns, for this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Loadhidden() *"+capsName+" {\n")
-	io.WriteString(_fo, "	self.Loadhidden_    	      = true\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "// Setloadhidden sets whether the subsequent load should expect and load hidden columns, for this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Setloadhidden(_loadhidden bool) *"+capsName+" {\n")
-	io.WriteString(_fo, "	self.Loadhidden_    	      = _loadhidden\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "// Status prints information about the recent load operation for this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Status(_verbose bool) *"+capsName+" {\n")
-	io.WriteString(_fo, "	if !_verbose { return self }\n")
-	io.WriteString(_fo, "	fmt.Println(\"LoadedFilename=\", self.LoadedFilename_, \"Numread=\", self.Numread_, \"Numrows=\", self.Numrows_)\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "// Nullkey sets whether subsequent load will balk at null keys, for this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Nullkey(_ok bool) *"+capsName+" {\n")
-	io.WriteString(_fo, "	self.Nullkey_    	      = _ok\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	for _, row := range yarr {
-		io.WriteString(_fo, "func (self *"+capsName+") SetInstance"+row.Name+"(_val "+row.Type+") *"+capsName+"{\n")
-		io.WriteString(_fo, " self."+row.Name+"_   = _val\n")
-		io.WriteString(_fo, "   return self\n")
-		io.WriteString(_fo, "}\n")
-		io.WriteString(_fo, "\n")
-	}
-	// ========================================================
-	io.WriteString(_fo, "// Clear forgets any previously read rows for this instance of "+capsName+"\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Clear() *"+capsName+" {\n")
-	for _, row := range sortedIndexVals {
-		io.WriteString(_fo, "	self.Map"+row.Name+"2"+capsName+"		= make(map["+row.Type+"]"+capsName+"ElemPtrSlice)\n")
-	}
-	io.WriteString(_fo, "	self.Numrows_	= 0\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-
-	io.WriteString(_fo, "// ShareAllRows shares each row with another "+capsName+" instance\n")
-	io.WriteString(_fo, "func (self *"+capsName+") ShareAllRows(_other *"+capsName+") *"+capsName+" {\n")
-	io.WriteString(_fo, "     for _, rows := range _other.Map"+favIM.Name+"2"+capsName+" {\n")
-	io.WriteString(_fo, "         for _, row := range rows {\n")
-	io.WriteString(_fo, "              if _, ok	:= self.AddRow(row); !ok {\n")
-	io.WriteString(_fo, "     	          fmt.Println(\""+capsName+": error adding row \"); PrintRowSep(row, \";\", \"\\n\")\n")
-	io.WriteString(_fo, "              }\n")
-	io.WriteString(_fo, "         }\n")
-	io.WriteString(_fo, "     }\n")
-	io.WriteString(_fo, "     return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-
-	io.WriteString(_fo, "// PrintRows prints each rows in the specified slice\n")
-	io.WriteString(_fo, "func (self *"+capsName+") PrintRows(_rows "+capsName+"ElemPtrSlice) {\n")
-	io.WriteString(_fo, "     for idx, row := range _rows {\n")
-	io.WriteString(_fo, "         fmt.Println(\"=========idx=\", idx, \"====\")\n")
-	io.WriteString(_fo, "     	 self.PrintRow(row)\n")
-	io.WriteString(_fo, "     }\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// PrintDupeRows prints rows which share a particular index key, if more than one row shares that key\n")
-	io.WriteString(_fo, "func (self *"+capsName+") PrintDupeRows(_rows "+capsName+"ElemPtrSlice) {\n")
-	io.WriteString(_fo, "     if(len(_rows) < 2) { return }\n")
-	io.WriteString(_fo, "     for idx, row := range _rows {\n")
-	io.WriteString(_fo, "         fmt.Println(\"=========idx=\", idx, \"====\")\n")
-	io.WriteString(_fo, "     	 self.PrintRow(row)\n")
-	io.WriteString(_fo, "     }\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// DropRow removes the row (with specified key and position) from each index it participates in, in reorder-UNSAFE manner\n")
-	io.WriteString(_fo, "func (self *"+capsName+") DropRow(_key string, _idx int) {\n")
-	io.WriteString(_fo, "    var rows "+capsName+"ElemPtrSlice\n")
-	io.WriteString(_fo, "    var ok bool\n")
-	for _, row := range sortedIndexVals {
-		switch row.Type {
-		case "string":
-			io.WriteString(_fo, "     rows, ok = self.Map"+row.Name+"2"+capsName+"[_key]; if !ok { return }\n")
-			io.WriteString(_fo, "     if _idx < len(rows) - 1 { rows[_idx] = rows[len(rows) - 1] }\n")
-			io.WriteString(_fo, "     self.Map"+row.Name+"2"+capsName+"[_key] = rows[:len(rows)-1]\n")
-		default:
-		}
-	}
-	io.WriteString(_fo, "     self.Numrows_--\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	if needDropRowInt64 {
-		io.WriteString(_fo, "func (self *"+capsName+") DropRowInt64(_key int64, _idx int) {\n")
-		io.WriteString(_fo, "    var rows "+capsName+"ElemPtrSlice\n")
-		io.WriteString(_fo, "    var ok bool\n")
-		for _, row := range sortedIndexVals {
-			switch row.Type {
-			case "int64":
-				io.WriteString(_fo, "     rows, ok = self.Map"+row.Name+"2"+capsName+"[_key]; if !ok { return }\n")
-				io.WriteString(_fo, "     if _idx < len(rows) - 1 { rows[_idx] = rows[len(rows) - 1] }\n")
-				io.WriteString(_fo, "     self.Map"+row.Name+"2"+capsName+"[_key] = rows[:len(rows)-1]\n")
-			default:
-			}
-		}
-		io.WriteString(_fo, "}\n")
-		io.WriteString(_fo, "\n")
-	}
-
-	// ========================================================
-	io.WriteString(_fo, "// PrintRow prints this row using columns separated by comma and row terminated by newline\n")
-	io.WriteString(_fo, "func (self *"+capsName+") PrintRow(_row "+capsName+"ElemPtr) {\n")
-	io.WriteString(_fo, "	PrintRowSep(_row, \"\\n\", \"\")\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// PrintRowSep prints this row using specified inter-column and end-of-row separator\n")
-	io.WriteString(_fo, "func PrintRowSep(_row "+capsName+"ElemPtr, _sep string, _sepEnd string) {\n")
-	for _, row := range arr {
-		if row.Hidden {
-			continue
-		}
-		io.WriteString(_fo, "	fmt.Print(\""+row.Name+endUnder+"=\", _row."+row.Name+endUnder+");fmt.Print(_sep)\n")
-	}
-	io.WriteString(_fo, "	fmt.Print(_sepEnd)\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// SprintRowSep prints this row to a string, using specified inter-column and end-of-row separator\n")
-	io.WriteString(_fo, "func SprintRowSep(_row "+capsName+"ElemPtr, _sep string, _sepEnd string) string {\n")
-	io.WriteString(_fo, " str	:= \"\"\n")
-	for _, row := range arr {
-		if row.Hidden {
-			continue
-		}
-		io.WriteString(_fo, "	str += fmt.Sprint(\""+row.Name+endUnder+"=\", _row."+row.Name+endUnder+"); str += fmt.Sprint(_sep)\n")
-	}
-	io.WriteString(_fo, "	str += fmt.Sprint(_sepEnd)\n")
-	io.WriteString(_fo, "   return str\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// loadElem loads one row of the file\n")
-	io.WriteString(_fo, "func (self *"+capsName+") loadElem(_bsl bslice) (row *"+capsName+"Elem) {\n")
-	io.WriteString(_fo, "   lenslice	  := len(_bsl)\n")
-	io.WriteString(_fo, "   ii, jj, mm, print := 0, 0, 1, false\n")
-	io.WriteString(_fo, "   row   = new("+capsName+"Elem)\n")
-	ctrlMCheck := " false            "
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		if row.Type == "" {
-			row.Type = "string"
-		} // default empty type to string
-		if (!row.LastShown) && (!row.Last) {
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+"_); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		} else if row.LastShown && row.Last { // (LastShown == Last) implies no hidden columns
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		} else if row.LastShown { // (LastShown != Last) implies that hidden columns follow
-			io.WriteString(_fo, "if !self.Loadhidden_ {\n")
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			io.WriteString(_fo, "} else {\n")
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		} else if row.Last { // (LastShown != Last) so this is the last hidden column
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			io.WriteString(_fo, "}\n")
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		}
-	}
-	io.WriteString(_fo, "   _, ok := self.AddRow(row)\n")
-	io.WriteString(_fo, "   if !ok { fmt.Println(\""+opt.Pkg+" bad row=\", string(_bsl)) }\n")
-	io.WriteString(_fo, "   return row\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// ProcRowFunc"+capsName+" processes one row of the file, useful for processing very large files in streaming manner\n")
-	io.WriteString(_fo, "type ProcRowFunc"+capsName+" func(_row *"+capsName+"Elem) bool\n")
-
-	io.WriteString(_fo, "func (self *"+capsName+") procElem(_bsl bslice, _procRowFunc ProcRowFunc"+capsName+") (row *"+capsName+"Elem) {\n")
-	io.WriteString(_fo, "   lenslice	 := len(_bsl)\n")
-	io.WriteString(_fo, "   ii, jj, mm, print := 0, 0, 1, false\n")
-	io.WriteString(_fo, "   row   = new("+capsName+"Elem)\n")
-	ctrlMCheck = " false            "
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		if (!row.LastShown) && (!row.Last) {
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		} else if row.LastShown && row.Last { // (LastShown == Last) implies no hidden columns
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		} else if row.LastShown { // (LastShown != Last) implies that hidden columns follow
-			io.WriteString(_fo, "if !self.Loadhidden_ {\n")
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			io.WriteString(_fo, "} else {\n")
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--; mm = 2}; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		} else if row.Last { // (LastShown != Last) so this is the last hidden column
-			switch row.Type {
-			case "string":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = strings.TrimSpace(string(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "bool":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToBool(strings.TrimSpace(string(_bsl[ii:jj])),false); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "int64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),0); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyymmdd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToInt(strings.TrimSpace(string(_bsl[ii:jj])),19000101); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "yyyy_mm_dd":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.YYYY_MM_DD2yyyymmdd(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+", row."+row.Name+"_hhmmss"+endUnder+", row."+row.Name+"_mmm"+endUnder+", row."+row.Name+"_zz"+endUnder+" = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			case "float64":
-				io.WriteString(_fo, "   for ii = jj; jj < lenslice ; jj++ { if((_bsl[jj] == comma) || (jj+1 == lenslice)){ if "+ctrlMCheck+" {jj--        }; row."+row.Name+endUnder+" = genutil.ToFloat(bytes.TrimSpace(_bsl[ii:jj])); if(print) { fmt.Println(\""+row.Name+"=\", row."+row.Name+endUnder+"); }; jj +=mm; break; } }\n")
-			default:
-				panic("unhandled Type_ of field=" + row.Type)
-			}
-			io.WriteString(_fo, "}\n")
-			ctrlMCheck = "_bsl[jj-1] == ''"
-		}
-	}
-	io.WriteString(_fo, "   ok := _procRowFunc(row)\n")
-	io.WriteString(_fo, "   if !ok { fmt.Println(\""+opt.Pkg+" bad row=\", string(_bsl)) }\n")
-	io.WriteString(_fo, "   return row\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// AddRow adds a row into the in-memory representation of thie file format\n")
-	io.WriteString(_fo, "func (self *"+capsName+") AddRow(_row *"+capsName+"Elem) (*"+capsName+"Elem, bool) {\n")
-	io.WriteString(_fo, "   goodnum, ok := 0, true\n")
-	if needDropRowInt64 {
-		io.WriteString(_fo, "    var ki int64\n")
-	}
-	io.WriteString(_fo, "    var kk string\n")
-	warnOnFirstIndex := true
-	for _, im := range sortedIndexVals { // loop thru all the discovered indexes
-		switch im.Type {
-		case "int64":
-			io.WriteString(_fo, "    ki = _row."+im.Rows[0]+"_;")
-			io.WriteString(_fo, "   if true { self.Map"+im.Name+"2"+capsName+"[ki]  = append(self.Map"+im.Name+"2"+capsName+"[ki], _row) ; goodnum++ }\n")
-		case "string":
-			kk := ""                      // initialize the multipart key to the null string
-			for ii, ip := range im.Rows { // loop thru all parts of this multipart index
-				if ii > 0 {
-					kk = kk + " + \"" + im.Sep + "\" + "
-				} // if it is not the first part, add keysep
-				kk = kk + " _row." + ip + endUnder // build up the multipart key by appending a part
-			}
-			// now output the statement to add the filerow to this index
-			io.WriteString(_fo, "    kk = "+kk+"; ")
-			io.WriteString(_fo, "   if((len(kk) > 0) || self.Nullkey_) { self.Map"+im.Name+"2"+capsName+"[kk]  = append(self.Map"+im.Name+"2"+capsName+"[kk], _row) ; goodnum++ }")
-			if warnOnFirstIndex {
-				warnOnFirstIndex = false
-				io.WriteString(_fo, " else { fmt.Println(\"AddRow:"+capsName+": WARNING: Empty key will not get row added to outputting map\") }")
-			}
-			io.WriteString(_fo, "\n")
-		}
-		// io.WriteString(_fo, "   if(len(_row." + row.Name + endUnder + ") > 0) { self.Map" + row.Name + "2" + capsName + "[_row." + row.Name + endUnder + "]  = append(self.Map" + row.Name + "2" + capsName + "[_row." + row.Name + endUnder + "], _row) ; goodnum++ }\n")
-	}
-
-	io.WriteString(_fo, "   if(goodnum == 0) { ok = false; self.PrintRow(_row) } else { self.Numrows_++ } \n")
-	io.WriteString(_fo, "   return _row, ok\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	// create functions for each index
-	//		(1) that will find existing (or newly create an unadded) element using that index
-	//		(2) that will test if there is an existing element using that index
-	for _, im := range sortedIndexVals { // loop thru all the discovered indexes
-		switch im.Type {
-		case "int64":
-			io.WriteString(_fo, "// FindOrNew"+im.Name+" returns slice consisting of all rows with matching key of specific named index\n")
-			io.WriteString(_fo, "//    If no such rows exist, it creates an initialized slice of one row (but does not add that row)\n")
-			io.WriteString(_fo, "func (self *"+capsName+") FindOrNew"+im.Name+"(_ki int64) ("+capsName+"ElemPtrSlice, bool) {\n")
-			io.WriteString(_fo, "	rows, ok	:= self.Map"+im.Name+"2"+capsName+"[_ki]\n")
-			io.WriteString(_fo, "	if ok { return rows, true }\n")
-			io.WriteString(_fo, "	rows   = []"+capsName+"ElemPtr{new("+capsName+"Elem)}\n")
-			io.WriteString(_fo, "	self.ClearRow(rows[0])\n")
-			io.WriteString(_fo, "	return rows, false\n")
-			io.WriteString(_fo, "}\n")
-			io.WriteString(_fo, "\n")
-
-			io.WriteString(_fo, "// HasMap"+im.Name+" returns bool testing if there exists atleast 1 row with matching key of specific named index\n")
-			io.WriteString(_fo, "func (self *"+capsName+") HasMap"+im.Name+"(_ki int64) bool {\n")
-			io.WriteString(_fo, "	rows, ok	:= self.Map"+im.Name+"2"+capsName+"[_ki]\n")
-			io.WriteString(_fo, "	return ok && (len(rows) > 0) && (rows[0] != nil)\n")
-			io.WriteString(_fo, "}\n")
-			io.WriteString(_fo, "\n")
-
-		case "string":
-			io.WriteString(_fo, "// FindOrNew"+im.Name+" returns slice consisting of all rows with matching key of specific named index\n")
-			io.WriteString(_fo, "//    If no such rows exist, it creates an initialized slice of one row (but does not add that row)\n")
-			io.WriteString(_fo, "func (self *"+capsName+") FindOrNew"+im.Name+"(_kk string) ("+capsName+"ElemPtrSlice, bool) {\n")
-			io.WriteString(_fo, "	rows, ok	:= self.Map"+im.Name+"2"+capsName+"[_kk]\n")
-			io.WriteString(_fo, "	if ok { return rows, true }\n")
-			io.WriteString(_fo, "	rows   = []"+capsName+"ElemPtr{new("+capsName+"Elem)}\n")
-			io.WriteString(_fo, "	self.ClearRow(rows[0])\n")
-			io.WriteString(_fo, "	return rows, false\n")
-			io.WriteString(_fo, "}\n")
-			io.WriteString(_fo, "\n")
-
-			io.WriteString(_fo, "// HasMap"+im.Name+" returns bool testing if there exists atleast 1 row with matching key of specific named index\n")
-			io.WriteString(_fo, "func (self *"+capsName+") HasMap"+im.Name+"(_kk string) bool {\n")
-			io.WriteString(_fo, "	rows, ok	:= self.Map"+im.Name+"2"+capsName+"[_kk]\n")
-			io.WriteString(_fo, "	return ok && (len(rows) > 0) && (rows[0] != nil)\n")
-			io.WriteString(_fo, "}\n")
-			io.WriteString(_fo, "\n")
-		}
-	}
-
-	// create function to return sorted values and sorted keys for each index
-	for _, im := range sortedIndexVals { // loop thru all the discovered indexes
-		switch im.Type {
-		case "int64":
-			io.WriteString(_fo, "// SortedKeys_Map"+im.Name+"2"+capsName+" returns slice consisting of keys in the specific named index\n")
-			io.WriteString(_fo, "func (self *"+capsName+") SortedKeys_Map"+im.Name+"2"+capsName+"() []int64 {\n")
-			io.WriteString(_fo, "	keys := make([]int, len(self.Map"+im.Name+"2"+capsName+"))\n")
-			io.WriteString(_fo, "	ii	:= 0\n")
-			io.WriteString(_fo, "	for kk := range self.Map"+im.Name+"2"+capsName+"{\n")
-			io.WriteString(_fo, "		keys[ii] = int(kk)\n")
-			io.WriteString(_fo, "		ii++\n")
-			io.WriteString(_fo, "        }\n")
-			io.WriteString(_fo, "		sort.Ints(keys)\n")
-			io.WriteString(_fo, "		vals := make([]int64, len(keys))\n")
-			io.WriteString(_fo, "		ii = 0\n")
-			io.WriteString(_fo, "		for ii, kk := range keys {\n")
-			io.WriteString(_fo, "			vals[ii] = int64(kk)\n")
-			io.WriteString(_fo, "               }\n")
-			io.WriteString(_fo, "	return vals}\n")
-			io.WriteString(_fo, "\n")
-
-			io.WriteString(_fo, "// Sorted_Map"+im.Name+"2"+capsName+" returns slice (whose each elem is a slice of row with specific key value) for sorted keys of a specific index\n")
-			io.WriteString(_fo, "func (self *"+capsName+") Sorted_Map"+im.Name+"2"+capsName+"() []"+capsName+"ElemPtrSlice {\n")
-			io.WriteString(_fo, "	keys := make([]int, len(self.Map"+im.Name+"2"+capsName+"))\n")
-			io.WriteString(_fo, "	ii	:= 0\n")
-			io.WriteString(_fo, "	for kk := range self.Map"+im.Name+"2"+capsName+"{\n")
-			io.WriteString(_fo, "		keys[ii] = int(kk)\n")
-			io.WriteString(_fo, "		ii++\n")
-			io.WriteString(_fo, "       }\n")
-			io.WriteString(_fo, "		sort.Ints(keys)\n")
-			io.WriteString(_fo, "		vals := make([]"+capsName+"ElemPtrSlice, len(keys))\n")
-			io.WriteString(_fo, "		ii = 0\n")
-			io.WriteString(_fo, "		for ii, kk := range keys {\n")
-			io.WriteString(_fo, "			vals[ii] = self.Map"+im.Name+"2"+capsName+"[int64(kk)]\n")
-			io.WriteString(_fo, "       }\n")
-			io.WriteString(_fo, "	return vals}\n")
-			io.WriteString(_fo, "\n")
-
-		case "string":
-			io.WriteString(_fo, "// SortedKeys_Map"+im.Name+"2"+capsName+" returns slice consisting of keys in the specific named index\n")
-			io.WriteString(_fo, "func (self *"+capsName+") SortedKeys_Map"+im.Name+"2"+capsName+"() []string {\n")
-			io.WriteString(_fo, "	keys := make([]string, len(self.Map"+im.Name+"2"+capsName+"))\n")
-			io.WriteString(_fo, "	ii	:= 0\n")
-			io.WriteString(_fo, "	for kk := range self.Map"+im.Name+"2"+capsName+"{\n")
-			io.WriteString(_fo, "		keys[ii] = kk\n")
-			io.WriteString(_fo, "		ii++\n")
-			io.WriteString(_fo, "       }\n")
-			io.WriteString(_fo, "		sort.Strings(keys)\n")
-			io.WriteString(_fo, "	return keys}\n")
-			io.WriteString(_fo, "\n")
-
-			io.WriteString(_fo, "// Sorted_Map"+im.Name+"2"+capsName+" returns slice (whose each elem is a slice of row with specific key value) for sorted keys of a specific index\n")
-			io.WriteString(_fo, "func (self *"+capsName+") Sorted_Map"+im.Name+"2"+capsName+"() []"+capsName+"ElemPtrSlice {\n")
-			io.WriteString(_fo, "	keys := make([]string, len(self.Map"+im.Name+"2"+capsName+"))\n")
-			io.WriteString(_fo, "	ii	:= 0\n")
-			io.WriteString(_fo, "	for kk := range self.Map"+im.Name+"2"+capsName+"{\n")
-			io.WriteString(_fo, "		keys[ii] = kk\n")
-			io.WriteString(_fo, "		ii++\n")
-			io.WriteString(_fo, "}\n")
-			io.WriteString(_fo, "		sort.Strings(keys)\n")
-			io.WriteString(_fo, "		vals := make([]"+capsName+"ElemPtrSlice, len(keys))\n")
-			io.WriteString(_fo, "		ii = 0\n")
-			io.WriteString(_fo, "		for ii, kk := range keys {\n")
-			io.WriteString(_fo, "			vals[ii] = self.Map"+im.Name+"2"+capsName+"[kk]\n")
-			io.WriteString(_fo, "}\n")
-			io.WriteString(_fo, "	return vals}\n")
-			io.WriteString(_fo, "\n")
-
-		}
-	}
-
-	// ========================================================
-	io.WriteString(_fo, "// Load loads all the rows from a file to the in-memory representation\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Load (_fname string) *"+capsName+"{\n")
-	io.WriteString(_fo, "    rr := genutil.OpenAny(_fname)\n")
-	io.WriteString(_fo, "    if rr == nil {\n")
-	io.WriteString(_fo, "	panic(\""+capsName+": Load : bad file=\" + _fname)\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "    numread, numbad := 0, 0\n")
-	io.WriteString(_fo, "    for first := true;;first = false {\n")
-	io.WriteString(_fo, "        bsl, err	:= rr.ReadSlice('"+"\\"+"n')\n")
-	io.WriteString(_fo, "        if err != nil && err != io.EOF { log.Panicf(\""+capsName+".Load: Error (%s) in ReadSlice for fname(%s)\", err.Error(), _fname) }\n")
-	io.WriteString(_fo, "	if(err == io.EOF) { break }\n")
-	io.WriteString(_fo, "	if(len(bsl) < 1) { numbad++; continue }\n")
-
-	ii := 0
-	switch opt.HeaderStyle {
-	case "external":
-		io.WriteString(_fo, "	if(")
-		for ii = 0; ii < len(arr[0].Headerstring); ii++ {
-			iis := strconv.FormatInt(int64(ii), 10)
-			iic := fmt.Sprintf("%c", arr[0].Headerstring[ii])
-			io.WriteString(_fo, "(bsl["+iis+"] == '"+iic+"') && ")
-		}
-		io.WriteString(_fo, "(bsl["+strconv.FormatInt(int64(len(arr[0].Headerstring)), 10)+"] == ',')) { if(!first) { numbad++ }; continue }\n")
-	default:
-		io.WriteString(_fo, "	if(")
-		for ii = 0; ii < len(arr[0].Name); ii++ {
-			iis := strconv.FormatInt(int64(ii), 10)
-			iic := fmt.Sprintf("%c", arr[0].Name[ii])
-			io.WriteString(_fo, "(bsl["+iis+"] == '"+iic+"') && ")
-		}
-		io.WriteString(_fo, "(bsl["+strconv.FormatInt(int64(len(arr[0].Name)), 10)+"] == ',')) { if(!first) { numbad++ }; continue }\n")
-	}
-
-	io.WriteString(_fo, "	if(!first) {\n")
-	io.WriteString(_fo, "		self.loadElem(bsl)\n")
-	io.WriteString(_fo, "		numread++\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "  if !self.Silent_ {  fmt.Println(\""+opt.Pkg+" numread=\", numread, \" numbad=\", numbad,\n")
-	done1 := false
-	for _, row := range sortedIndexVals {
-		if done1 {
-			io.WriteString(_fo, "		     ,")
-		} else {
-			io.WriteString(_fo, "		     ")
-		}
-		io.WriteString(_fo, "\" num"+row.Name+"=\", len(self.Map"+row.Name+"2"+capsName+")")
-		done1 = true
-	}
-	io.WriteString(_fo, "	, genutil.FileInfo(_fname, \" \", false))\n")
-	for _, row := range sortedIndexVals {
-		io.WriteString(_fo, "    // fmt.Println(\""+row.Name+"s : ======\"); for _, rows := range self.Map"+row.Name+"2"+capsName+" { self.PrintRows(rows) }\n")
-	}
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, " if len(self.LoadedFilename_) == 0 {self.LoadedFilename_=_fname} else {self.LoadedFilename_ += \";\" + _fname}\n")
-	io.WriteString(_fo, "   self.Numread_		= numread\n")
-	io.WriteString(_fo, "   return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// LoadIfExists loads all the rows from a file to the in-memory representation, but does not fail if the filename does not exist\n")
-	io.WriteString(_fo, "func (self *"+capsName+") LoadIfExists (_fname string) *"+capsName+"{\n")
-	io.WriteString(_fo, "	if genutil.AnyPathOK(_fname) { return self.Load(_fname) }\n")
-	io.WriteString(_fo, "		return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// LoadBuf loads all the rows from a buffer into the in-memory representation, but does not fail if the filename does not exist\n")
-	io.WriteString(_fo, "func (self *"+capsName+") LoadBuf (_fname string, _buffer []byte) *"+capsName+"{\n")
-	io.WriteString(_fo, "    numread, buflen := 0, len(_buffer)\n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "	// skip header\n")
-	io.WriteString(_fo, "    rowBegin := 0\n")
-	io.WriteString(_fo, "    rowEnd := genutil.IndexNl (_buffer, buflen, 0)\n")
-	io.WriteString(_fo, "    if rowEnd > buflen { log.Panicf(\""+capsName+".LoadBuf: Error: Failed to parse newline in buffer for fname(%s)\", _fname) }\n")
-	io.WriteString(_fo, "    rowBegin = rowEnd \n")
-	io.WriteString(_fo, "\n")
-	io.WriteString(_fo, "	// parse the remaining lines\n")
-	io.WriteString(_fo, "	for ; rowBegin < buflen; rowBegin = rowEnd {\n")
-	io.WriteString(_fo, "   	rowEnd = genutil.IndexNl (_buffer, buflen, rowBegin)\n")
-	io.WriteString(_fo, "		bsl := _buffer[rowBegin:rowEnd]\n")
-	io.WriteString(_fo, "		self.loadElem(bsl)\n")
-	io.WriteString(_fo, "		numread++\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "   if !self.Silent_ {  fmt.Println(\""+opt.Pkg+" numread=\", numread) }\n")
-	io.WriteString(_fo, "   self.LoadedFilename_=_fname\n")
-	io.WriteString(_fo, "   return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// Proc processes all the rows from a file but unlike Load it does not put them into the in-memory representation\n")
-	io.WriteString(_fo, "func (self *"+capsName+") Proc (_fname string, _procRowFunc ProcRowFunc"+capsName+") *"+capsName+"{\n")
-	io.WriteString(_fo, "    rr := genutil.OpenAny(_fname)\n")
-	io.WriteString(_fo, "    if rr == nil {\n")
-	io.WriteString(_fo, "	panic(\""+capsName+": Proc : bad file=\" + _fname)\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "    numread, numbad := 0, 0\n")
-	io.WriteString(_fo, "    for first := true;;first = false {\n")
-	io.WriteString(_fo, "        bsl, err	:= rr.ReadSlice('"+"\\"+"n')\n")
-	io.WriteString(_fo, "        if err != nil && err != io.EOF { log.Panicf(\""+capsName+".Proc: Error (%s) in ReadSlice for fname(%s)\", err.Error(), _fname) }\n")
-	io.WriteString(_fo, "	if(err == io.EOF) { break }\n")
-	io.WriteString(_fo, "	if(len(bsl) < 1) { numbad++; continue }\n")
-
-	io.WriteString(_fo, "	if(")
-	qq := 0
-	for qq = 0; qq < len(arr[0].Name); qq++ {
-		qqs := strconv.FormatInt(int64(qq), 10)
-		qqc := fmt.Sprintf("%c", arr[0].Name[qq])
-		io.WriteString(_fo, "(bsl["+qqs+"] == '"+qqc+"') && ")
-	}
-	io.WriteString(_fo, "(bsl["+strconv.FormatInt(int64(len(arr[0].Name)), 10)+"] == ',')) { if(!first) { numbad++ }; continue }\n")
-
-	io.WriteString(_fo, "	if(!first) {\n")
-	io.WriteString(_fo, "		self.procElem(bsl, _procRowFunc)\n")
-	io.WriteString(_fo, "		numread++\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "    }\n")
-	io.WriteString(_fo, "  if !self.Silent_ {  fmt.Println(\""+opt.Pkg+" numread=\", numread, \" numbad=\", numbad,\n")
-	done2 := false
-	for _, row := range sortedIndexVals {
-		if done2 {
-			io.WriteString(_fo, "		     ,")
-		} else {
-			io.WriteString(_fo, "		     ")
-		}
-		io.WriteString(_fo, "\" num"+row.Name+"=\", len(self.Map"+row.Name+"2"+capsName+")")
-		done2 = true
-	}
-	io.WriteString(_fo, "	, \"fname=\", _fname	)\n")
-	for _, row := range sortedIndexVals {
-		io.WriteString(_fo, "    // fmt.Println(\""+row.Name+"s : ======\"); for _, rows := range self.Map"+row.Name+"2"+capsName+" { self.PrintRows(rows) }\n")
-	}
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, " if len(self.LoadedFilename_) == 0 {self.LoadedFilename_=_fname} else {self.LoadedFilename_ += \";\" + _fname}\n")
-	io.WriteString(_fo, "   self.Numread_		= numread\n")
-	io.WriteString(_fo, "   return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// ProcFuncSample illustrates how to use Proc\n")
-	io.WriteString(_fo, " func ProcFuncSample(_row *"+capsName+"Elem) bool {\n")
-	io.WriteString(_fo, " 	fmt.Println(\"foo\")\n")
-	io.WriteString(_fo, " 	return true\n")
-	io.WriteString(_fo, " }\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// SortwriteFile writes the in-memory representation to file, in sorted order \n")
-	io.WriteString(_fo, "func (self *"+capsName+") SortwriteFile(_ofile string) *"+capsName+" {\n")
-	io.WriteString(_fo, "	ww	:= genutil.OpenGzFile(_ofile)\n")
-	io.WriteString(_fo, "	defer ww.Close()\n")
-	io.WriteString(_fo, "	count := 0\n")
-	io.WriteString(_fo, "	hdr := \"")
-
-	for _, row := range arr {
-		if row.Hidden {
-			continue
-		}
-		if !row.FirstShown {
-			io.WriteString(_fo, ",")
-		}
-		switch opt.HeaderStyle {
-		case "external":
-			io.WriteString(_fo, row.Headerstring)
-		default:
-			io.WriteString(_fo, row.Name)
-		}
-		if row.LastShown {
-			io.WriteString(_fo, "\"\n")
-		}
-	}
-
-	io.WriteString(_fo, "	fmt.Fprintf(ww, \"%s\\n\", hdr)\n")
-	io.WriteString(_fo, "	for _, rows := range self.Sorted_Map"+favIM.Name+"2"+capsName+"() {\n")
-	io.WriteString(_fo, "		count += self.WriteRows(ww, rows)\n")
-	io.WriteString(_fo, "	}\n")
-	io.WriteString(_fo, "	if false { fmt.Println(\""+capsName+".SortwriteFile: ofile=\", _ofile, \"count=\", count) }\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// WriteFile writes the in-memory representation to file\n")
-	io.WriteString(_fo, "func (self *"+capsName+") WriteFile(_ofile string) *"+capsName+" {\n")
-	io.WriteString(_fo, "	ww	:= genutil.OpenGzFile(_ofile)\n")
-	io.WriteString(_fo, "	defer ww.Close()\n")
-	io.WriteString(_fo, "	count := 0\n")
-	io.WriteString(_fo, "	hdr := \"")
-
-	for _, row := range arr {
-		if row.Hidden {
-			continue
-		}
-		if !row.FirstShown {
-			io.WriteString(_fo, ",")
-		}
-		switch opt.HeaderStyle {
-		case "external":
-			io.WriteString(_fo, row.Headerstring)
-		default:
-			io.WriteString(_fo, row.Name)
-		}
-		if row.LastShown {
-			io.WriteString(_fo, "\"\n")
-		}
-	}
-
-	io.WriteString(_fo, "	fmt.Fprintf(ww, \"%s\\n\", hdr)\n")
-	io.WriteString(_fo, "	for _, rows := range self.Map"+sortedIndexVals[0].Name+"2"+capsName+" {\n")
-	io.WriteString(_fo, "		count += self.WriteRows(ww, rows)\n")
-	io.WriteString(_fo, "	}\n")
-	io.WriteString(_fo, "	if false { fmt.Println(\""+capsName+".WriteFile: ofile=\", _ofile, \"count=\", count) }\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// WriteFileHidden writes the in-memory representation, including hidden columns, to file\n")
-	io.WriteString(_fo, "func (self *"+capsName+") WriteFileHidden(_ofile string) *"+capsName+" {\n")
-	io.WriteString(_fo, "	ww	:= genutil.OpenGzFile(_ofile)\n")
-	io.WriteString(_fo, "	defer ww.Close()\n")
-	io.WriteString(_fo, "	count := 0\n")
-	io.WriteString(_fo, "	hdr := \"")
-
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		// if row.Hidden { continue }
-		if !row.Footer {
-			if !row.FirstShown {
-				io.WriteString(_fo, ",")
-			}
-			switch opt.HeaderStyle {
-			case "external":
-				io.WriteString(_fo, row.Headerstring)
-			default:
-				io.WriteString(_fo, row.Name)
-			}
-		}
-		if row.Last {
-			io.WriteString(_fo, "\"\n")
-		}
-	}
-
-	io.WriteString(_fo, "	fmt.Fprintf(ww, \"%s\\n\", hdr)\n")
-	io.WriteString(_fo, "	for _, rows := range self.Map"+sortedIndexVals[0].Name+"2"+capsName+" {\n")
-	io.WriteString(_fo, "		count += self.WriteRowsHidden(ww, rows)\n")
-	io.WriteString(_fo, "	}\n")
-	io.WriteString(_fo, "	if false { fmt.Println(\""+capsName+".WriteFileHidden: ofile=\", _ofile, \"count=\", count) }\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// SortwriteFileHidden writes the in-memory representation, including hidden columns, to file, in sorted order\n")
-	io.WriteString(_fo, "func (self *"+capsName+") SortwriteFileHidden(_ofile string) *"+capsName+" {\n")
-	io.WriteString(_fo, "	ww	:= genutil.OpenGzFile(_ofile)\n")
-	io.WriteString(_fo, "	defer ww.Close()\n")
-	io.WriteString(_fo, "	count := 0\n")
-	io.WriteString(_fo, "	hdr := \"")
-
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		if !row.Footer {
-			// if row.Hidden { continue }
-			if !row.FirstShown {
-				io.WriteString(_fo, ",")
-			}
-			switch opt.HeaderStyle {
-			case "external":
-				io.WriteString(_fo, row.Headerstring)
-			default:
-				io.WriteString(_fo, row.Name)
+	for _, im := range sortedIndexVals {
+		for pos, name := range im.Rows {
+			if len(name) < 1 {
+				errs = append(errs, &SpecError{Line: im.Line, Name: im.Name, Err: fmt.Sprintf("index %s has no column at position %d", im.Name, pos)})
+			} else if len(im.Rows) > 1 || im.Type == "string" {
+				if row := types[name]; row.OutType != "string" {
+					bad(row, fmt.Sprintf("column %s is %s, but the key of index %s is a string, so only string columns can be in it", name, row.Type, im.Name))
+				}
+			} else if im.Type != "int64" && im.Type != "time.Time" {
+				errs = append(errs, &SpecError{Line: im.Line, Name: im.Name, Err: "index " + im.Name + " is on a column of type " + im.Type + ", but only string, int64 and time columns can be indexed"})
 			}
 		}
-		if row.Last {
-			io.WriteString(_fo, "\"\n")
-		}
 	}
 
-	io.WriteString(_fo, "	fmt.Fprintf(ww, \"%s\\n\", hdr)\n")
-	io.WriteString(_fo, "	for _, rows := range self.Sorted_Map"+favIM.Name+"2"+capsName+"() {\n")
-	io.WriteString(_fo, "		count += self.WriteRowsHidden(ww, rows)\n")
-	io.WriteString(_fo, "	}\n")
-	io.WriteString(_fo, "	if false { fmt.Println(\""+capsName+".SortwriteFileHidden: ofile=\", _ofile, \"count=\", count) }\n")
-	io.WriteString(_fo, "	return self\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// WriteRows writes the rows in the passed slice\n")
-	io.WriteString(_fo, "func (self *"+capsName+") WriteRows(_ww io.Writer, _rows "+capsName+"ElemPtrSlice) int {\n")
-	io.WriteString(_fo, "     count := 0\n")
-	io.WriteString(_fo, "     for _, row := range _rows {\n")
-	io.WriteString(_fo, "     	 self.WriteRow(_ww, row)\n")
-	io.WriteString(_fo, "     count = count + 1\n")
-	io.WriteString(_fo, "     }\n")
-	io.WriteString(_fo, "     return count\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// WriteRowsHidden writes the rows, including hidden columns, in the passed slice\n")
-	io.WriteString(_fo, "func (self *"+capsName+") WriteRowsHidden(_ww io.Writer, _rows "+capsName+"ElemPtrSlice) int {\n")
-	io.WriteString(_fo, "     count := 0\n")
-	io.WriteString(_fo, "     for _, row := range _rows {\n")
-	io.WriteString(_fo, "     	 self.WriteRowHidden(_ww, row)\n")
-	io.WriteString(_fo, "     count = count + 1\n")
-	io.WriteString(_fo, "     }\n")
-	io.WriteString(_fo, "     return count\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// WriteRow writes the specified row\n")
-	io.WriteString(_fo, "func (self *"+capsName+") WriteRow(_ww io.Writer, _row "+capsName+"ElemPtr) {\n")
-	for _, row := range arr {
-		if row.Hidden {
-			continue
-		}
-		nlval := ""
-		if !row.FirstShown {
-			nlval = ","
-		}
-		switch row.Type {
-		case "string":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", _row."+row.Name+endUnder+")\n")
-		case "bool":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatBool(_row."+row.Name+endUnder+"))\n")
-		case "int64":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "yyyymmdd":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "yyyy_mm_dd":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "float64":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatFloat(_row."+row.Name+endUnder+", 'f', 6, 64))\n")
-		default:
-			panic("unhandled Type_ of field=" + row.Type)
-		}
+	if len(sortedIndexVals) <= 0 {
+		errs = append(errs, &SpecError{Err: "there is no index: put *index in the hasindex cell of a column"})
+	} else if favName == "" {
+		errs = append(errs, &SpecError{Err: "no index is tagged as favourite (to be used in Sortwrite*): put a * before its index in a hasindex cell"})
 	}
-	io.WriteString(_fo, "	fmt.Fprintf(_ww, \"\\n\")")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-
-	// ========================================================
-	io.WriteString(_fo, "// WriteRowHidden writes the specified row, including hidden columns\n")
-	io.WriteString(_fo, "func (self *"+capsName+") WriteRowHidden(_ww io.Writer, _row "+capsName+"ElemPtr) {\n")
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		nlval := ""
-		if !row.FirstShown {
-			nlval = ","
-		}
-		switch row.Type {
-		case "string":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", _row."+row.Name+endUnder+")\n")
-		case "bool":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatBool(_row."+row.Name+endUnder+"))\n")
-		case "int64":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "yyyymmdd":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "yyyy_mm_dd":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatInt(_row."+row.Name+endUnder+", 10))\n")
-		case "float64":
-			io.WriteString(_fo, "	fmt.Fprintf(_ww, \""+nlval+"%s\", strconv.FormatFloat(_row."+row.Name+endUnder+", 'f', 6, 64))\n")
-		default:
-			panic("unhandled Type_ of field=" + row.Type)
-		}
+	if len(errs) > 0 {
+		return nil, nil, errs
 	}
-	io.WriteString(_fo, "	fmt.Fprintf(_ww, \"\\n\")")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
+	return sortedIndexVals, indexMap[favName], nil
+}
 
-	// ========================================================
-	io.WriteString(_fo, "// ClearRow clears the specified row\n")
-	io.WriteString(_fo, "func (self *"+capsName+") ClearRow(_row "+capsName+"ElemPtr) {\n")
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		switch row.Type {
-		case "string":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= \"\"\n")
-		case "bool":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= false\n")
-		case "int64":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= 0\n")
-		case "yyyymmdd":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= 19000101\n")
-		case "yyyy_mm_dd":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= 19000101\n")
-		case "YYYY_MM_DD_HH_MM_SS_mmm_zz":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= 19000101\n")
-		case "float64":
-			io.WriteString(_fo, "	_row."+row.Name+endUnder+"	= 0.0\n")
-		default:
-			panic("unhandled Type_ of field=" + row.Type)
-		}
+// Generate returns the source of the package for the hcsv format of _spec, gofmt'd.
+// If the source does not parse, it is returned unformatted along with the error, to help locate the error
+func Generate(_spec *Spec, _opt Options) ([]byte, error) {
+	data, err := newGenData(_spec, _opt)
+	if err != nil {
+		return nil, err
 	}
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// CopyRow copies the specified row and returns the copy\n")
-	io.WriteString(_fo, "func CopyRow(_from, _to "+capsName+"ElemPtr) "+capsName+"ElemPtr {\n")
-	for _, row := range arr {
-		if row.Header || row.Footer {
-			continue
-		}
-		io.WriteString(_fo, "	_to."+row.Name+endUnder+"	= _from."+row.Name+endUnder+"\n")
-	}
-	io.WriteString(_fo, "  return _to\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
-	io.WriteString(_fo, "// LoadMustIfBiz will load from the file, but will panic if unable to load when isBiz is true\n")
-	io.WriteString(_fo, "func (self *"+capsName+") LoadMustIfBiz(_fname string, _isBiz bool) *"+capsName+" {\n")
-	io.WriteString(_fo, "    if _isBiz {	return self.Load(_fname) }\n")
-	io.WriteString(_fo, "    return self.LoadIfExists(_fname)	// Loading is not mandatory on nonbiz day\n")
-	io.WriteString(_fo, "}\n")
-	io.WriteString(_fo, "\n")
-	// ========================================================
+	return execTemplate("package.tmpl", data, true)
 }
 
-func main() {
-	if parseArgs() {
-		fmt.Println("gencsv ============================================================================================= starting")
-
-		fo, err := os.Create(opt.Ofile)
-		if err != nil {
-			panic(err)
-		}
-		defer fo.Close()
-		ft, err := os.Create(opt.TestMain)
-		if err != nil {
-			panic(err)
-		}
-		defer ft.Close()
-		fd, err := os.Create(opt.TestBash)
-		if err != nil {
-			panic(err)
-		}
-		defer fd.Close()
-
-		loadSpec(opt.Cfg)
-
-		writePre(fo)
-		writeStruct(fo)
-		writeStructMore(fo)
-		writeTest(ft)
-		writeDoit(fd)
-		genutil.BashExecOrDie(true, "chmod 775 "+opt.TestBash, ".")
-		fmt.Println("gencsv ============================================================================================= done")
-	} else {
-		// Assume being called in gencfg mode - creates spec file from the single csv parameter
-		fmt.Println("name,headerstring,type,hasindex,finaltype")
-		parts := strings.Split(os.Args[1], ",")
-		for _, row1 := range parts {
-			row := row1
-			row = strings.Replace(row, ".", "_", -1)
-			row = strings.Replace(row, "/", "_", -1)
-			row = strings.Replace(row, "-", "_", -1)
-			row = strings.Replace(row, "&", "_", -1)
-			row = strings.Replace(row, "\"", "", -1)
-			row = strings.Replace(row, "[", "", -1)
-			row = strings.Replace(row, "]", "", -1)
-			row = strings.Replace(row, "(", "", -1)
-			row = strings.Replace(row, ")", "", -1)
-			row = strings.Replace(row, "%", "Pct", -1)
-			row = strings.Replace(row, "$", "USD", -1)
-			row = strings.Replace(row, "#", "Num", -1)
-			row = strings.Replace(row, " ", "", -1)
-			row = strings.ToUpper(row[0:1]) + strings.ToLower(row[1:])
-			str := row + "," + row1 + ",,,"
-			fmt.Println(str)
-		}
+// GenerateTest returns the source of the <pkg>_test.go file for the package Generate makes.
+// Its tests load a synthetic file with a sample value of its type in each cell, write it and load it again,
+// and look up each row in each index
+func GenerateTest(_spec *Spec, _opt Options) ([]byte, error) {
+	_opt.Log = nil
+	data, err := newGenData(_spec, _opt)
+	if err != nil {
+		return nil, err
 	}
+	return execTemplate("test.tmpl", data, true)
 }
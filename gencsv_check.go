@@ -0,0 +1,64 @@
+package gencsv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// hashComment starts the line of the generated package that holds the hash of its spec and options (see package.tmpl)
+const hashComment = "// gencsv spec hash: "
+
+// ErrStale is returned by Check when a generated package is not the one its spec and options would generate
+var ErrStale = errors.New("generated package is stale, rerun gencsv")
+
+// specHash returns the hash of the spec rows of _spec, of the settings in _data that change the generated package,
+// and of the templates that write it, so that a new version of gencsv changes the hash too
+func specHash(_spec *Spec, _data *genData) string {
+	hh := sha256.New()
+	entries, _ := fs.ReadDir(templateFS, "templates")
+	for _, entry := range entries {
+		text, _ := fs.ReadFile(templateFS, "templates/"+entry.Name())
+		fmt.Fprintf(hh, "%s %d\n", entry.Name(), len(text))
+		hh.Write(text)
+	}
+	for _, rows := range []GENCSVElemPtrSlice{_spec.Cols, _spec.Inst} {
+		for _, row := range rows {
+			fmt.Fprintf(hh, "%q,%q,%q,%q,%q\n", row.Name, headerCell(row), row.Type, row.Hasindex, row.Finaltype)
+		}
+		fmt.Fprintln(hh)
+	}
+	fmt.Fprintf(hh, "Pkg=%q Caps=%q U=%q Delim=%q HeaderStyle=%q Genutil=%q\n", _data.Pkg, _data.Caps, _data.U, _data.Delim, _data.HeaderStyle, _data.Genutil)
+	return hex.EncodeToString(hh.Sum(nil))
+}
+
+// Check tests that _src, the source of a generated package, is what Generate makes of _spec and _opt, byte for byte.
+// It returns ErrStale if not. The hash line is only a quick first check: a hand edit of the package is found too
+func Check(_spec *Spec, _opt Options, _src []byte) error {
+	_opt.Log = nil
+	data, err := newGenData(_spec, _opt)
+	if err != nil {
+		return err
+	}
+	hashed := false
+	for _, line := range bytes.Split(_src, []byte("\n")) {
+		if line = bytes.TrimSpace(line); bytes.HasPrefix(line, []byte(hashComment)) {
+			hashed = string(bytes.TrimSpace(line[len(hashComment):])) == data.Hash
+			break
+		}
+	}
+	if !hashed {
+		return ErrStale
+	}
+	src, err := Generate(_spec, _opt)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(src, _src) {
+		return ErrStale
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package gencsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// inferKinds lists the types that --Infer can propose, in order of preference when several fit every cell of a column
+var inferKinds = []string{"bool", "yyyymmdd", "int64", "float64", "yyyy_mm_dd"}
+
+// MemberName returns the golang-legal member name for the column named _col
+func MemberName(_col string) string {
+	row := _col
+	row = strings.Replace(row, ".", "_", -1)
+	row = strings.Replace(row, "/", "_", -1)
+	row = strings.Replace(row, "-", "_", -1)
+	row = strings.Replace(row, "&", "_", -1)
+	row = strings.Replace(row, "\"", "", -1)
+	row = strings.Replace(row, "[", "", -1)
+	row = strings.Replace(row, "]", "", -1)
+	row = strings.Replace(row, "(", "", -1)
+	row = strings.Replace(row, ")", "", -1)
+	row = strings.Replace(row, "%", "Pct", -1)
+	row = strings.Replace(row, "$", "USD", -1)
+	row = strings.Replace(row, "#", "Num", -1)
+	row = strings.Replace(row, " ", "", -1)
+	if len(row) < 1 {
+		return row
+	}
+	return strings.ToUpper(row[0:1]) + strings.ToLower(row[1:])
+}
+
+// isDigits tests that _str is made of digits only
+func isDigits(_str string) bool {
+	for ii := 0; ii < len(_str); ii++ {
+		if _str[ii] < '0' || _str[ii] > '9' {
+			return false
+		}
+	}
+	return len(_str) > 0
+}
+
+// isDate tests that the year, month and day are plausible
+func isDate(_yyyy, _mm, _dd string) bool {
+	yy, _ := strconv.Atoi(_yyyy)
+	mm, _ := strconv.Atoi(_mm)
+	dd, _ := strconv.Atoi(_dd)
+	return yy >= 1900 && yy <= 2199 && mm >= 1 && mm <= 12 && dd >= 1 && dd <= 31
+}
+
+// fitsKind tests that the non-empty, trimmed cell _str can be loaded as type _kind
+func fitsKind(_kind, _str string) bool {
+	switch _kind {
+	case "bool":
+		switch strings.ToLower(_str) {
+		case "true", "false":
+			return true
+		}
+		return false
+	case "yyyymmdd":
+		return len(_str) == 8 && isDigits(_str) && isDate(_str[0:4], _str[4:6], _str[6:8])
+	case "int64":
+		_, err := strconv.ParseInt(_str, 10, 64)
+		return err == nil
+	case "float64":
+		_, err := strconv.ParseFloat(_str, 64)
+		return err == nil
+	case "yyyy_mm_dd":
+		return len(_str) == 10 && strings.IndexByte("-_/", _str[4]) >= 0 && _str[7] == _str[4] &&
+			isDigits(_str[0:4]) && isDigits(_str[5:7]) && isDigits(_str[8:10]) && isDate(_str[0:4], _str[5:7], _str[8:10])
+	}
+	return false
+}
+
+// inferCol gathers what the scanned rows tell about one column
+type inferCol struct {
+	name   string
+	kinds  map[string]bool // types still fitting every non-empty cell
+	seen   map[string]bool // values seen, to find unique columns
+	filled int             // number of non-empty cells
+	unique bool            // no value repeats, and none is empty
+}
+
+// kind returns the type proposed for the column: the first of inferKinds fitting every non-empty cell, or string
+func (self *inferCol) kind() string {
+	if self.filled > 0 { // an empty column stays a string
+		for _, kk := range inferKinds {
+			if self.kinds[kk] {
+				return kk
+			}
+		}
+	}
+	return "string"
+}
+
+// Infer reads the header and up to _maxrows rows of sample data from _rr, separated by _opt.Delim, and writes to _fo a spec file
+// proposing a type for each column, with the first string or int64 column whose values are unique as the favourite index.
+// It returns the names of all the string and int64 columns whose values are unique, as candidate indexes
+func Infer(_fo io.Writer, _rr io.Reader, _opt Options, _maxrows int) ([]string, error) {
+	_, delim, err := parseDelim(_opt.Delim)
+	if err != nil {
+		return nil, err
+	}
+	rr := csv.NewReader(_rr)
+	rr.Comma = rune(delim)
+	rr.FieldsPerRecord = -1
+	rr.LazyQuotes = true
+
+	hdr, err := rr.Read()
+	if err != nil {
+		return nil, errors.New("cannot read header row: " + err.Error())
+	}
+	cols := make([]*inferCol, len(hdr))
+	for ii, name := range hdr {
+		cols[ii] = &inferCol{name: strings.TrimSpace(name), kinds: map[string]bool{}, seen: map[string]bool{}, unique: true}
+		for _, kind := range inferKinds {
+			cols[ii].kinds[kind] = true
+		}
+	}
+	numrows := 0
+	for ; numrows < _maxrows; numrows++ {
+		cells, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read row %d: %v", numrows+1, err)
+		}
+		for ii, col := range cols {
+			str := ""
+			if ii < len(cells) {
+				str = strings.TrimSpace(cells[ii])
+			}
+			if str == "" || col.seen[str] {
+				col.unique = false
+			}
+			col.seen[str] = true
+			if str == "" {
+				continue
+			}
+			col.filled++
+			for kind := range col.kinds {
+				if !fitsKind(kind, str) {
+					delete(col.kinds, kind)
+				}
+			}
+		}
+	}
+
+	members, taken := make([]string, len(cols)), map[string]bool{}
+	for ii, col := range cols {
+		members[ii] = inferMember(col.name, taken)
+	}
+	candidates := []string{}
+	for ii, col := range cols {
+		if col.unique && numrows > 1 && (col.kind() == "string" || col.kind() == "int64") { // the types an index can be on
+			candidates = append(candidates, members[ii])
+		}
+	}
+	fmt.Fprintln(_fo, specHeader)
+	for ii, col := range cols {
+		index := ""
+		if len(candidates) > 0 && members[ii] == candidates[0] {
+			index = "*index"
+		}
+		header := specCell.Replace(col.name)
+		if header != col.name {
+			fmt.Fprintf(_fo, "# %s is named %q in the sample, which a spec file cannot hold\n", members[ii], col.name)
+		}
+		fmt.Fprintln(_fo, members[ii]+","+header+","+col.kind()+","+index+",")
+	}
+	return candidates, nil
+}
+
+// specCell replaces the characters that a headerstring cell of a spec file cannot hold: the cell separator, the alias separator,
+// and those Lint rejects in a header name
+var specCell = strings.NewReplacer(",", " ", "|", " ", "\"", "", "\\", " ", "\r", " ", "\n", " ")
+
+// inferMember returns the member name of the column named _name: MemberName, with any other character that is not
+// legal in a golang identifier replaced by _, and a number appended if a column before it, in _taken, has that name already
+func inferMember(_name string, _taken map[string]bool) string {
+	member := []rune(MemberName(_name))
+	for ii, rr := range member {
+		if !unicode.IsLetter(rr) && !unicode.IsDigit(rr) && rr != '_' {
+			member[ii] = '_'
+		}
+	}
+	name := string(member)
+	if len(member) < 1 || !unicode.IsUpper(member[0]) {
+		name = "Col" + name
+	}
+	base := name
+	for nn := 2; _taken[name]; nn++ {
+		name = base + strconv.Itoa(nn)
+	}
+	_taken[name] = true
+	return name
+}
@@ -0,0 +1,230 @@
+package gencsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// specJSON is the structured form of a spec file, with explicit sections for the columns, indexes, instance variables and options
+type specJSON struct {
+	Columns  []*columnJSON   `json:"columns"`
+	Indexes  []*indexJSON    `json:"indexes"`
+	Instance []*instanceJSON `json:"instance,omitempty"`
+	Options  *Options        `json:"options,omitempty"`
+}
+
+// columnJSON is a column of a structured spec, with its finaltype spelled out
+type columnJSON struct {
+	Name         string      `json:"name"`
+	Headerstring string      `json:"headerstring,omitempty"`
+	Aliases      []string    `json:"aliases,omitempty"`  // further header names the column is accepted under
+	Type         string      `json:"type,omitempty"`     // string if empty
+	Hidden       bool        `json:"hidden,omitempty"`   // not read or written, unless by the *Hidden funcs
+	Header       bool        `json:"header,omitempty"`   // a member of the preamble row
+	Footer       bool        `json:"footer,omitempty"`   // a member of the trailer row
+	Rowcount     bool        `json:"rowcount,omitempty"` // a footer holding the number of rows
+	Optional     bool        `json:"optional,omitempty"` // may be missing from a file, and then loads as default
+	Default      string      `json:"default,omitempty"`
+	Nullable     bool        `json:"nullable,omitempty"` // an empty cell loads as null rather than as the zero value
+	Format       string      `json:"format,omitempty"`   // how a numeric column is written: [+|(][,][.N][f|e|g]
+	Extra        []*xattJSON `json:"extra,omitempty"`    // further members, named <name>_<extra name>_
+}
+
+// xattJSON is a further member of a column
+type xattJSON struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// indexJSON is an index of a structured spec: its columns in key order, joined by sep in multi-column keys
+type indexJSON struct {
+	Name      string   `json:"name"`
+	Columns   []string `json:"columns"`
+	Sep       string   `json:"sep,omitempty"`
+	Favourite bool     `json:"favourite,omitempty"` // the order of the Sortwrite* funcs
+}
+
+// instanceJSON is an instance variable of a structured spec
+type instanceJSON struct {
+	Name         string `json:"name"`
+	Headerstring string `json:"headerstring,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Sort         bool   `json:"sort,omitempty"` // generate funcs sorting the PointerMap by this variable
+}
+
+// LoadSpecJSON reads a structured spec from _rr. Its rows are added as if read from the equivalent spec file,
+// and its options section is kept in the Options of the spec
+func LoadSpecJSON(_rr io.Reader) (*Spec, error) {
+	var sj specJSON
+	dec := json.NewDecoder(_rr)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sj); err != nil {
+		return nil, err
+	}
+	self := NewSpec()
+	if sj.Options != nil {
+		self.Options = *sj.Options
+	}
+
+	// the hasindex cell of each column lists the indexes it is in, the favourite one marked by a * where it first appears
+	hasindex := map[string][]string{}
+	cols := map[string]bool{}
+	for _, cj := range sj.Columns {
+		cols[cj.Name] = true
+	}
+	favs := 0
+	for _, ij := range sj.Indexes {
+		for _, name := range ij.Columns {
+			if !cols[name] {
+				return nil, &SpecError{Name: ij.Name, Err: "index has column " + name + ", which is not in the columns"}
+			}
+		}
+		if ij.Favourite {
+			favs++
+		}
+		if len(ij.Columns) < 1 {
+			return nil, &SpecError{Name: ij.Name, Err: "index has no columns"}
+		}
+		if strings.ContainsAny(ij.Sep, ",*") || strings.Contains(ij.Sep, "index(") {
+			return nil, &SpecError{Name: ij.Name, Err: fmt.Sprintf("index sep %q holds a comma, a * or index(", ij.Sep)}
+		}
+	}
+	if favs > 1 {
+		return nil, &SpecError{Err: "more than one index is favourite"}
+	}
+	for _, cj := range sj.Columns {
+		simple := false
+		for _, ij := range sj.Indexes {
+			for pos, name := range ij.Columns {
+				if name != cj.Name {
+					continue
+				}
+				star := ""
+				if ij.Favourite {
+					star, ij.Favourite = "*", false // only where it first appears
+				}
+				hasindex[cj.Name] = append(hasindex[cj.Name], star+"index("+ij.Name+"="+strconv.Itoa(pos)+"="+ij.Sep+")")
+				simple = len(ij.Columns) == 1 && ij.Name == cj.Name && (ij.Sep == "" || ij.Sep == ":")
+			}
+		}
+		if ixs := hasindex[cj.Name]; len(ixs) == 1 && simple { // the simple form, which keeps the type of the column
+			hasindex[cj.Name][0] = ixs[0][:strings.Index(ixs[0], "(")]
+		}
+	}
+
+	for _, cj := range sj.Columns {
+		finals := []string{}
+		switch {
+		case cj.Header:
+			finals = append(finals, "header")
+		case cj.Footer && cj.Rowcount:
+			finals = append(finals, "footer:rowcount")
+		case cj.Footer:
+			finals = append(finals, "footer")
+		case cj.Hidden:
+			finals = append(finals, "hidden")
+		}
+		if cj.Nullable {
+			finals = append(finals, "nullable")
+		}
+		if cj.Optional {
+			if strings.ContainsAny(cj.Default, ",/") {
+				return nil, &SpecError{Name: cj.Name, Err: fmt.Sprintf("default %q holds a comma or a /", cj.Default)}
+			}
+			finals = append(finals, strings.TrimSuffix("optional:"+cj.Default, ":"))
+		}
+		for _, xj := range cj.Extra {
+			finals = append(finals, xj.Name+":"+xj.Type)
+		}
+		if cj.Format != "" { // last, as for a time column it takes the rest of the finaltype
+			finals = append(finals, "format:"+cj.Format)
+		}
+		if _, err := self.AddRow(cj.Name, strings.Join(append([]string{cj.Headerstring}, cj.Aliases...), "|"), cj.Type, strings.Join(hasindex[cj.Name], ""), strings.Join(finals, "/")); err != nil {
+			return nil, err
+		}
+		self.Numread++
+	}
+	for _, ij := range sj.Instance {
+		ix := ""
+		if ij.Sort {
+			ix = "sort"
+		}
+		if _, err := self.AddRow(ij.Name, ij.Headerstring, ij.Type, ix, "instance"); err != nil {
+			return nil, err
+		}
+		self.Numread++
+	}
+	return self, nil
+}
+
+// WriteJSON writes the spec to _ww in the structured form, with the indexes described by its hasindex cells, and its Options
+func (self *Spec) WriteJSON(_ww io.Writer) error {
+	indexes, fav, err := makeIndexes(self.Cols, io.Discard)
+	if err != nil {
+		return err
+	}
+	sj := specJSON{}
+	for _, row := range self.Cols {
+		cj := &columnJSON{Name: row.Name, Headerstring: row.Headerstring, Aliases: row.Aliases, Type: row.Type,
+			Hidden: row.Hidden && !row.Header && !row.Footer, Header: row.Header, Footer: row.Footer, Rowcount: row.FooterCount,
+			Optional: row.Optional, Default: row.Default, Nullable: row.Nullable, Format: row.Numfmt}
+		for _, xx := range row.Xarr {
+			cj.Extra = append(cj.Extra, &xattJSON{Name: strings.TrimSuffix(strings.TrimPrefix(xx.Xname, row.Name+"_"), "_"), Type: xx.Xtype})
+		}
+		sj.Columns = append(sj.Columns, cj)
+	}
+	for _, im := range indexes {
+		sj.Indexes = append(sj.Indexes, &indexJSON{Name: im.Name, Columns: im.Rows, Sep: im.Sep, Favourite: im == fav})
+	}
+	for _, row := range self.Inst {
+		sj.Instance = append(sj.Instance, &instanceJSON{Name: row.Name, Headerstring: row.Headerstring, Type: row.Type, Sort: row.Hasindex == "sort"})
+	}
+	if self.Options != (Options{}) {
+		sj.Options = &self.Options
+	}
+	enc := json.NewEncoder(_ww)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&sj)
+}
+
+// WriteCSV writes the spec to _ww as a spec file. Its Options, which a spec file cannot hold, are written as a comment
+func (self *Spec) WriteCSV(_ww io.Writer) error {
+	if args := self.Options.args(); len(args) > 0 {
+		if _, err := fmt.Fprintln(_ww, "# options:"+args); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(_ww, specHeader); err != nil {
+		return err
+	}
+	for _, rows := range []GENCSVElemPtrSlice{self.Cols, self.Inst} {
+		for _, row := range rows {
+			cells := []string{row.Name, headerCell(row), row.Type, row.Hasindex, row.Finaltype}
+			if cells[3] == "noindex" {
+				cells[3] = ""
+			}
+			if cells[4] == "none" {
+				cells[4] = ""
+			}
+			if _, err := fmt.Fprintln(_ww, strings.Join(cells, ",")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// args returns the options that are set, as commandline parameters
+func (self Options) args() string {
+	str := ""
+	for _, kv := range [][2]string{{"Pkg", self.Pkg}, {"CapsPkg", self.CapsPkg}, {"HeaderStyle", self.HeaderStyle}, {"Underscore", self.Underscore},
+		{"Delim", self.Delim}, {"Module", self.Module}, {"ImportPrefix", self.ImportPrefix}} {
+		if len(kv[1]) > 0 {
+			str += " --" + kv[0] + " " + kv[1]
+		}
+	}
+	return str
+}
@@ -0,0 +1,135 @@
+package gencsv
+
+import (
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SpecError is a problem with a spec row, at a line of the spec file (or 0 if it is not from a file)
+type SpecError struct {
+	Line int
+	Name string // of the spec row or index, if known
+	Err  string
+}
+
+func (self *SpecError) Error() string {
+	str := self.Err
+	if len(self.Name) > 0 {
+		str = self.Name + ": " + str
+	}
+	if self.Line > 0 {
+		str = "line " + strconv.Itoa(self.Line) + ": " + str
+	}
+	return str
+}
+
+// SpecErrors holds the problems Lint finds in a spec, in spec line order, followed by those of the whole spec
+type SpecErrors []*SpecError
+
+func (self SpecErrors) Error() string {
+	strs := make([]string, len(self))
+	for ii, se := range self {
+		strs[ii] = se.Error()
+	}
+	return strings.Join(strs, "\n")
+}
+
+// foldName returns the header name _name as aliases are matched: in lower case, without white space
+func foldName(_name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(_name), ""))
+}
+
+// Lint returns every problem of the spec that would stop its package from being generated or compiled:
+// lines that are not spec rows, names that are not exported golang identifiers or are repeated,
+// unknown column types, header names holding a quote, backslash, newline or the field separator,
+// header names accepted for more than one column, defaults that do not fit their type,
+// bad formats, and bad, incomplete or missing indexes
+func (self *Spec) Lint() SpecErrors {
+	errs := append(SpecErrors{}, self.Bad...)
+	bad := func(_row *GENCSVElem, _err string) {
+		errs = append(errs, &SpecError{Line: _row.Line, Name: _row.Name, Err: _err})
+	}
+	seen := map[string]*GENCSVElem{}
+	for _, rows := range []GENCSVElemPtrSlice{self.Cols, self.Inst} {
+		for _, row := range rows {
+			if !token.IsIdentifier(row.Name) || !token.IsExported(row.Name) {
+				bad(row, "name is not a golang identifier starting with an upper-case letter")
+			}
+			if first, ok := seen[row.Name]; ok {
+				bad(row, "name is repeated, first at line "+strconv.Itoa(first.Line))
+			} else {
+				seen[row.Name] = row
+			}
+			for _, xx := range row.Xarr {
+				if !token.IsIdentifier(xx.Xname) {
+					bad(row, "extra member "+xx.Xname+" is not a golang identifier")
+				}
+			}
+		}
+	}
+	for _, row := range self.Cols {
+		if _, err := makeType(row.Type); err != nil {
+			bad(row, err.Error())
+		}
+	}
+	_, sep, err := parseDelim(self.Options.Delim)
+	if err != nil {
+		errs = append(errs, &SpecError{Err: err.Error()})
+	}
+	for _, row := range self.Cols {
+		for _, name := range append([]string{row.Headerstring}, row.Aliases...) {
+			if strings.ContainsAny(name, "\"\\\r\n") || (sep != 0 && strings.IndexByte(name, sep) >= 0) {
+				bad(row, "header name "+strconv.Quote(name)+" holds a quote, backslash, newline or the field separator "+strconv.Quote(string(sep)))
+			}
+		}
+	}
+	folded := map[string]*GENCSVElem{} // the header names each column is found under, as aliases are matched
+	for _, row := range self.Cols {
+		for _, name := range []string{row.Name, row.Headerstring} {
+			if key := foldName(name); len(key) > 0 && folded[key] == nil {
+				folded[key] = row
+			}
+		}
+	}
+	for _, row := range self.Cols {
+		if (row.Header || row.Footer) && len(row.Aliases) > 0 {
+			bad(row, "header and footer members are found by position, so cannot have aliases")
+		}
+		if (row.Header || row.Footer) && row.Optional {
+			bad(row, "header and footer members are found by position, so cannot be optional")
+		}
+		ct := lookupType(row.Type)
+		if row.Numfmt != "" && (ct == nil || ct.Time == nil && ct.Formats[row.Numfmt] == "") { // a time column takes any layout
+			if _, err := parseNumFormat(row.Numfmt, row.Type); err != nil {
+				bad(row, err.Error())
+			}
+		}
+		if ct != nil && ct.Time != nil && row.Numfmt != "" { // a default in the layout of the format loads too
+			ct = writtenAs(ct, row.Numfmt)
+		}
+		if dflt := strings.TrimSpace(row.Default); ct != nil && dflt != "" && !ct.fits(dflt) {
+			bad(row, "default "+row.Default+" is not a valid "+ct.Name)
+		}
+		for _, alias := range row.Aliases {
+			if first := folded[foldName(alias)]; first != nil && first != row {
+				bad(row, "alias "+alias+" is also a header name of "+first.Name+" (aliases match regardless of case and white space)")
+			} else {
+				folded[foldName(alias)] = row
+			}
+		}
+	}
+	if _, _, err := makeIndexes(self.Cols, io.Discard); err != nil {
+		errs = append(errs, err.(SpecErrors)...)
+	}
+	line := func(_se *SpecError) int {
+		if _se.Line == 0 {
+			return int(^uint(0) >> 1) // problems of the whole spec come last
+		}
+		return _se.Line
+	}
+	sort.SliceStable(errs, func(ii, jj int) bool { return line(errs[ii]) < line(errs[jj]) })
+	return errs
+}
@@ -0,0 +1,447 @@
+package gencsv
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadSpecString loads the spec file _text, failing the test if it cannot be read at all
+func loadSpecString(_t *testing.T, _text string) *Spec {
+	self, err := LoadSpec(strings.NewReader(_text))
+	if err != nil {
+		_t.Fatal(err)
+	}
+	return self
+}
+
+// lintWant is a problem Lint should find: its line (0 for the whole spec), and a piece of its message
+type lintWant struct {
+	Line int
+	Err  string
+}
+
+// TestLint lints spec files with one or two problems each, which should be reported at their lines, in line order
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		delim string
+		spec  string
+		want  []lintWant
+	}{
+		{"clean", "", specHeader + "\nId,,string,*index,\nAmt,Amount|amt usd,float64,,optional:1.25\nWhen,,time(2006-01-02),,format:20060102\n", nil},
+		{"no header line", "", "Id,,string,*index,\nAmt,,float64,,\n", []lintWant{{1, "first line is not the header"}, {0, "there is no index"}}},
+		{"short row", "", specHeader + "\nId,,string,*index,\nAmt,,float64\n", []lintWant{{3, "has 3 cells, want 5"}}},
+		{"repeated header line", "", specHeader + "\nId,,string,*index,\n" + specHeader + "\n", []lintWant{{3, "repeated header line"}}},
+		{"unknown type", "", specHeader + "\nId,,string,*index,\nAmt,,float32,,\n", []lintWant{{3, "unknown type float32"}}},
+		{"bad type parameters", "", specHeader + "\nId,,string,*index,\nAmt,,decimal(20,2),,\nQty,,int64(3),,\n",
+			[]lintWant{{3, "precision of 1 to 18"}, {4, "type int64 has no parameters"}}},
+		{"bad names", "", specHeader + "\nId,,string,*index,\namt,,float64,,\nId,,int64,,\n",
+			[]lintWant{{3, "not a golang identifier"}, {4, "name is repeated, first at line 2"}}},
+		{"bad defaults", "", specHeader + "\nId,,string,*index,\nAmt,,decimal(5,2),,optional:123.456\nOn,,yyyymmdd,,optional:20161301\n",
+			[]lintWant{{3, "default 123.456 is not a valid decimal(5,2)"}, {4, "default 20161301 is not a valid yyyymmdd"}}},
+		{"yyyy_mm_dd default as written back", "", specHeader + "\nId,,string,*index,\nOn,,yyyy_mm_dd,,optional:20160102\n", nil},
+		{"time default in the written layout", "", specHeader + "\nId,,string,*index,\nWhen,,time(20060102),,optional:2016-01-02/format:2006-01-02\n", nil},
+		{"bad header names", "", specHeader + "\nId,,string,*index,\nAmt,a\"b,float64,,\nQty,a\\b,int64,,\n",
+			[]lintWant{{3, "holds a quote"}, {4, "holds a quote"}}},
+		{"field separator in a header name", "semicolon", specHeader + "\nId,,string,*index,\nAmt,a;b,float64,,\n", []lintWant{{3, "field separator \";\""}}},
+		{"field separator of another format", "", specHeader + "\nId,,string,*index,\nAmt,a;b,float64,,\n", nil},
+		{"clashing aliases", "", specHeader + "\nId,,string,*index,\nAmt,Amount,float64,,\nTotal,,float64,,\nSum,Sum|AMOUNT,float64,,\n",
+			[]lintWant{{5, "alias AMOUNT is also a header name of Amt"}}},
+		{"aliased footer", "", specHeader + "\nId,,string,*index,\nCount,Count|N,int64,,footer:rowcount\n", []lintWant{{3, "cannot have aliases"}}},
+		{"bad format", "", specHeader + "\nId,,string,*index,\nAmt,,float64,,format:.2x\nQty,,int64,,format:x\nOk,,bool,,format:+\n",
+			[]lintWant{{3, "bad precision .2x"}, {4, "is not [+|(][,][.N][f|e|g]"}, {5, "only for float64, int64 and decimal columns"}}},
+		{"bad delim", "quote\"", specHeader + "\nId,,string,*index,\n", []lintWant{{0, "Delim must be one of"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := loadSpecString(t, tt.spec)
+			spec.Options.Delim = tt.delim
+			errs := spec.Lint()
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Lint found %d problems, want %d:\n%v", len(errs), len(tt.want), errs)
+			}
+			for ii, se := range errs {
+				if se.Line != tt.want[ii].Line || !strings.Contains(se.Err, tt.want[ii].Err) {
+					t.Errorf("problem %d is %q at line %d, want %q at line %d", ii, se.Err, se.Line, tt.want[ii].Err, tt.want[ii].Line)
+				}
+			}
+		})
+	}
+}
+
+// TestInfer infers the spec of sample files, checking the types, the member names and the index candidates it proposes
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		delim      string
+		sample     string
+		spec       []string // the rows proposed, after the header line
+		candidates []string
+	}{
+		{"types", "", "id,ok,day,qty,px,iso,note\na,true,20160102,1,1.5,2016-01-02,x\nb,FALSE,20161231,-2,2,2016-12-31,\n",
+			[]string{"Id,id,string,*index,", "Ok,ok,bool,,", "Day,day,yyyymmdd,,", "Qty,qty,int64,,", "Px,px,float64,,", "Iso,iso,yyyy_mm_dd,,", "Note,note,string,,"},
+			[]string{"Id", "Qty"}},
+		{"int64 index", "", "seq,name\n1,a\n2,a\n", []string{"Seq,seq,int64,*index,", "Name,name,string,,"}, []string{"Seq"}},
+		{"not a date", "", "day\n20161301\n20160101\n", []string{"Day,day,int64,*index,"}, []string{"Day"}},
+		{"one row has no candidates", "", "id\na\n", []string{"Id,id,string,,"}, []string{}},
+		{"empty column", "", "id,blank\na,\nb,\n", []string{"Id,id,string,*index,", "Blank,blank,string,,"}, []string{"Id"}},
+		{"tab separated", "tab", "id\tamt\na\t1.5\nb\t2\n", []string{"Id,id,string,*index,", "Amt,amt,float64,,"}, []string{"Id"}},
+		{"repeated member names", "", "a.b,a-b,A_B,2x,a b\n1,2,3,4,5\n",
+			[]string{"A_b,a.b,int64,,", "A_b2,a-b,int64,,", "A_b3,A_B,int64,,", "Col2x,2x,int64,,", "Ab,a b,int64,,"}, []string{}},
+		{"headers a spec cannot hold", "", "\"a,b\",\"x|y\",'q'\n1,2,3\n",
+			[]string{"# A_b is named \"a,b\" in the sample, which a spec file cannot hold", "A_b,a b,int64,,",
+				"# X_y is named \"x|y\" in the sample, which a spec file cannot hold", "X_y,x y,int64,,", "Col_q_,'q',int64,,"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			candidates, err := Infer(&out, strings.NewReader(tt.sample), Options{Delim: tt.delim}, 1000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := specHeader + "\n" + strings.Join(tt.spec, "\n") + "\n"
+			if out.String() != want {
+				t.Errorf("Infer wrote:\n%s\nwant:\n%s", out.String(), want)
+			}
+			if !reflect.DeepEqual(candidates, tt.candidates) {
+				t.Errorf("Infer proposed the candidates %q, want %q", candidates, tt.candidates)
+			}
+			if errs := loadSpecString(t, out.String()).Lint(); len(errs) > 0 && len(candidates) > 0 {
+				t.Errorf("Lint of the inferred spec:\n%v", errs)
+			}
+		})
+	}
+}
+
+// roundTripSpec is a spec file with every kind of column, index and finaltype, written as WriteCSV writes it
+const roundTripSpec = "# options: --Pkg rt --Underscore end --Delim pipe\n" + specHeader + `
+Asof,,yyyymmdd,,header
+Id,ID|Ident,string,*index,
+How,,string,index(How=0=/)index(WhyHow=1=:),
+Why,,string,index(WhyHow=0=:),
+Amt,Amount|amt usd,decimal(18,4),,nullable/optional:1.5/format:+,
+Px,,float64,,bid:float64/ask:float64/format:.2f
+When,,time(2006-01-02 15:04,America/New_York),,format:20060102
+Note,,string,,optional
+Seq,,int64,,hidden/optional:7
+Count,,int64,,footer:rowcount
+Src,,string,,instance
+Calctime,,time.Time,sort,instance
+`
+
+// TestJSONRoundTrip writes a spec in the structured form, loads that, and writes it as a spec file, which should give the spec file back
+func TestJSONRoundTrip(t *testing.T) {
+	spec := loadSpecString(t, roundTripSpec)
+	if errs := spec.Lint(); len(errs) > 0 {
+		t.Fatalf("Lint:\n%v", errs)
+	}
+	spec.Options = Options{Pkg: "rt", Underscore: "end", Delim: "pipe"} // as the commandline would set them
+	var csv bytes.Buffer
+	if err := spec.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if csv.String() != roundTripSpec {
+		t.Errorf("WriteCSV wrote:\n%s\nwant:\n%s", csv.String(), roundTripSpec)
+	}
+
+	var js bytes.Buffer
+	if err := spec.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	back, err := LoadSpecJSON(&js)
+	if err != nil {
+		t.Fatalf("LoadSpecJSON: %v\n%s", err, js.String())
+	}
+	if back.Options != spec.Options {
+		t.Errorf("LoadSpecJSON read the options %+v, want %+v", back.Options, spec.Options)
+	}
+	csv.Reset()
+	if err := back.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if csv.String() != roundTripSpec {
+		t.Errorf("WriteCSV of the structured spec wrote:\n%s\nwant:\n%s", csv.String(), roundTripSpec)
+	}
+	src, err := Generate(spec, spec.Options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(back, back.Options, src); err != nil {
+		t.Errorf("Check of the structured spec against the package of the spec file: %v", err)
+	}
+}
+
+// TestLoadSpecJSONErrors loads structured specs that have no equivalent spec file
+func TestLoadSpecJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown field", `{"columns": [{"name": "Id", "kind": "string"}]}`, "unknown field"},
+		{"unknown index column", `{"columns": [{"name": "Id"}], "indexes": [{"name": "Id", "columns": ["Key"]}]}`, "Key, which is not in the columns"},
+		{"index without columns", `{"columns": [{"name": "Id"}], "indexes": [{"name": "Id", "columns": []}]}`, "index has no columns"},
+		{"bad sep", `{"columns": [{"name": "Id"}], "indexes": [{"name": "Id", "columns": ["Id"], "sep": ","}]}`, "holds a comma"},
+		{"two favourites", `{"columns": [{"name": "Id"}], "indexes": [{"name": "A", "columns": ["Id"], "favourite": true}, {"name": "B", "columns": ["Id"], "favourite": true}]}`,
+			"more than one index is favourite"},
+		{"bad default", `{"columns": [{"name": "Id", "optional": true, "default": "a/b"}]}`, "holds a comma or a /"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSpecJSON(strings.NewReader(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadSpecJSON returned %v, want an error with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheck checks a generated package against its spec, which should pass, and against edits of either, which should not
+func TestCheck(t *testing.T) {
+	spec := loadSpecString(t, roundTripSpec)
+	opt := Options{Pkg: "rt", Underscore: "end"}
+	src, err := Generate(spec, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(spec, opt, src); err != nil {
+		t.Errorf("Check of the package just generated: %v", err)
+	}
+
+	changed := loadSpecString(t, strings.Replace(roundTripSpec, "Note,,string,,optional", "Note,,string,,", 1))
+	edited := bytes.Replace(src, []byte("\treturn"), []byte("\t// edited by hand\n\treturn"), 1)
+	noHash := bytes.Replace(src, []byte(hashComment), []byte("// "), 1)
+	tests := []struct {
+		name string
+		spec *Spec
+		opt  Options
+		src  []byte
+	}{
+		{"spec changed", changed, opt, src},
+		{"options changed", spec, Options{Pkg: "rt"}, src},
+		{"delim changed", spec, Options{Pkg: "rt", Underscore: "end", Delim: "tab"}, src},
+		{"package edited", spec, opt, edited},
+		{"no hash line", spec, opt, noHash},
+		{"empty", spec, opt, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(tt.spec, tt.opt, tt.src); !errors.Is(err, ErrStale) {
+				t.Errorf("Check returned %v, want ErrStale", err)
+			}
+		})
+	}
+}
+
+// TestParseDelim parses the names and characters Delim can be, and some it cannot
+func TestParseDelim(t *testing.T) {
+	tests := []struct {
+		delim   string
+		escaped string
+		sep     byte
+		ok      bool
+	}{
+		{"", ",", ',', true},
+		{"comma", ",", ',', true},
+		{"tab", "\\t", '\t', true},
+		{"\t", "\\t", '\t', true},
+		{"pipe", "|", '|', true},
+		{"semicolon", ";", ';', true},
+		{"%", "%", '%', true},
+		{"~", "~", '~', true},
+		{"\"", "", 0, false},
+		{"'", "", 0, false},
+		{"\\", "", 0, false},
+		{" ", "", 0, false},
+		{"\n", "", 0, false},
+		{";;", "", 0, false},
+		{"colon", "", 0, false},
+	}
+	for _, tt := range tests {
+		escaped, sep, err := parseDelim(tt.delim)
+		if escaped != tt.escaped || sep != tt.sep || (err == nil) != tt.ok {
+			t.Errorf("parseDelim(%q) = %q, %q, %v; want %q, %q, ok %v", tt.delim, escaped, sep, err, tt.escaped, tt.sep, tt.ok)
+		}
+	}
+}
+
+// TestMakeType makes the types with parameters, good and bad, and tests which cells fit them
+func TestMakeType(t *testing.T) {
+	tests := []struct {
+		typ    string
+		name   string // of the type made, or a piece of the error
+		fit    []string
+		notFit []string
+	}{
+		{"decimal(18,4)", "decimal(18,4)", []string{"1", "-1.5", "+0.0001", "12345678901234.1234", ".5", "007"}, []string{"1.23456", "123456789012345", "1e3", "--1", "1.2.3", "", "."}},
+		{"decimal(4,4)", "decimal(4,4)", []string{"0.1234", ".5", "-0"}, []string{"1.5", "0.12345"}},
+		{"decimal( 5 , 0 )", "decimal(5,0)", []string{"12345", "-00012345"}, []string{"1.5", "123456"}},
+		{"decimal(19,2)", "precision of 1 to 18", nil, nil},
+		{"decimal(4,5)", "precision of 1 to 18", nil, nil},
+		{"decimal(4)", "decimal needs 2 parameters", nil, nil},
+		{"decimal", "needs parameters", nil, nil},
+		{"time(20060102)", "time(20060102)", []string{"20160102"}, []string{"2016-01-02", "20161301"}},
+		{"time(2006-01-02 15:04:05,America/New_York)", "time(2006-01-02 15:04:05,America/New_York)", []string{"2016-01-02 09:30:00"}, []string{"2016-01-02"}},
+		{"time(Jan 2, 2006)", "time(Jan 2, 2006)", []string{"Feb 3, 2016"}, []string{"2016-02-03"}},
+		{"time(epochms,UTC)", "time(epochms,UTC)", []string{"1451606400000"}, []string{"2016-01-01"}},
+		{"time(epoch,Local)", "time(epoch,Local)", []string{"1451606400"}, []string{"x1"}},
+		{"time(20060102,Mars/Olympus)", "unknown location Mars/Olympus", nil, nil},
+		{"time()", "needs a layout", nil, nil},
+		{"time((2006))", "needs a layout", nil, nil},
+		{"int64(3)", "has no parameters", nil, nil},
+		{"yyyymmdd", "yyyymmdd", []string{"20160102"}, []string{"2016-01-02", "20161301"}},
+		{"yyyy_mm_dd", "yyyy_mm_dd", []string{"20160102", "2016-01-02"}, []string{"2016-13-02", "x"}},
+		{"string", "string", []string{"anything"}, nil},
+	}
+	for _, tt := range tests {
+		ct, err := makeType(tt.typ)
+		if err != nil {
+			if !strings.Contains(err.Error(), tt.name) {
+				t.Errorf("makeType(%q): %v, want an error with %q", tt.typ, err, tt.name)
+			}
+			continue
+		}
+		if ct.Name != tt.name {
+			t.Errorf("makeType(%q) is named %q, want %q", tt.typ, ct.Name, tt.name)
+		}
+		for _, str := range tt.fit {
+			if !ct.fits(str) {
+				t.Errorf("%s does not fit %q", tt.typ, str)
+			}
+		}
+		for _, str := range tt.notFit {
+			if ct.fits(str) {
+				t.Errorf("%s fits %q", tt.typ, str)
+			}
+		}
+	}
+}
+
+// TestParseNumFormat parses the formats of numeric columns, good and bad
+func TestParseNumFormat(t *testing.T) {
+	tests := []struct {
+		str  string
+		typ  string
+		want *numFormat // nil for an error
+	}{
+		{"", "float64", &numFormat{Prec: -1}},
+		{".2", "float64", &numFormat{Prec: 2, Verb: 'f'}},
+		{"+,.2f", "float64", &numFormat{Sign: '+', Thousands: true, Prec: 2, Verb: 'f'}},
+		{"(.3e", "float64", &numFormat{Sign: '(', Prec: 3, Verb: 'e'}},
+		{"g", "float64", &numFormat{Prec: -1, Verb: 'g'}},
+		{"+,", "int64", &numFormat{Sign: '+', Thousands: true, Prec: -1}},
+		{"(", "decimal(18,4)", &numFormat{Sign: '(', Prec: -1}},
+		{".2", "int64", nil},
+		{"e", "decimal(18,4)", nil},
+		{".x", "float64", nil},
+		{".31", "float64", nil},
+		{",+", "float64", nil},
+		{"fe", "float64", nil},
+		{"+", "string", nil},
+	}
+	for _, tt := range tests {
+		nf, err := parseNumFormat(tt.str, tt.typ)
+		switch {
+		case tt.want == nil && err == nil:
+			t.Errorf("parseNumFormat(%q, %q) = %+v, want an error", tt.str, tt.typ, nf)
+		case tt.want != nil && err != nil:
+			t.Errorf("parseNumFormat(%q, %q): %v", tt.str, tt.typ, err)
+		case tt.want != nil && *nf != *tt.want:
+			t.Errorf("parseNumFormat(%q, %q) = %+v, want %+v", tt.str, tt.typ, nf, tt.want)
+		}
+	}
+}
+
+// goTool runs the go tool with _args in _dir, failing the test with its output if it fails
+func goTool(_t *testing.T, _dir string, _args ...string) {
+	cmd := exec.Command("go", _args...)
+	cmd.Dir = _dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_t.Fatalf("go %s: %v\n%s", strings.Join(_args, " "), err, out)
+	}
+}
+
+// genutilModule copies the genutil package that gencsv itself builds with into _dir, as a module of its own for the demo packages,
+// skipping the test if the go tool cannot find it, or it needs packages other than the standard ones
+func genutilModule(_t *testing.T, _dir string) {
+	out, err := exec.Command("go", "list", "-deps", "-f", "{{if not .Standard}}{{.Dir}}{{end}}", "github.com/LDCS/genutil").Output()
+	dirs := strings.Fields(string(out))
+	if err != nil || len(dirs) != 1 {
+		_t.Skip("go list cannot find genutil alone:", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dirs[0], "*.go"))
+	if err := os.MkdirAll(_dir, 0777); err != nil {
+		_t.Fatal(err)
+	}
+	for _, fname := range files {
+		if strings.HasSuffix(fname, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(fname)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(_dir, filepath.Base(fname)), src, 0666)
+		}
+		if err != nil {
+			_t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(_dir, "go.mod"), []byte("module github.com/LDCS/genutil\n"), 0666); err != nil {
+		_t.Fatal(err)
+	}
+}
+
+// TestDemo generates the packages of the _demo spec files, with their tests, into a module, and vets and tests them there
+func TestDemo(t *testing.T) {
+	cfgs, _ := filepath.Glob(filepath.Join("_demo", "*.cfg"))
+	if len(cfgs) < 1 {
+		t.Fatal("no _demo spec files")
+	}
+	dir := t.TempDir()
+	for _, cfg := range cfgs {
+		pkg := strings.TrimSuffix(filepath.Base(cfg), ".cfg")
+		spec, err := LoadSpecFile(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := spec.Lint(); len(errs) > 0 {
+			t.Fatalf("%s:\n%v", cfg, errs)
+		}
+		opt := Options{Pkg: pkg, Underscore: "end", Module: "example.com/demo"}
+		src, err := Generate(spec, opt)
+		if err != nil {
+			t.Fatalf("%s: %v", cfg, err)
+		}
+		test, err := GenerateTest(spec, opt)
+		if err != nil {
+			t.Fatalf("%s: %v", cfg, err)
+		}
+		if err := Check(spec, opt, src); err != nil {
+			t.Errorf("%s: Check: %v", cfg, err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, pkg), 0777); err != nil {
+			t.Fatal(err)
+		}
+		for fname, text := range map[string][]byte{pkg + ".go": src, pkg + "_test.go": test} {
+			if err := os.WriteFile(filepath.Join(dir, pkg, fname), text, 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := exec.LookPath("go"); err != nil || testing.Short() {
+		t.Skip("not building the demo packages: no go tool, or -short")
+	}
+	genutilModule(t, filepath.Join(dir, "genutil"))
+	mod := "module example.com/demo\n\ngo 1.21\n\nrequire github.com/LDCS/genutil v0.0.0\n\nreplace github.com/LDCS/genutil => ./genutil\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	goTool(t, dir, "vet", "./...")
+	goTool(t, dir, "test", "./...")
+}
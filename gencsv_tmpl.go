@@ -0,0 +1,516 @@
+package gencsv
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The generated files are written by the templates in templates/, each named by its file name
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("gencsv").Funcs(template.FuncMap{
+	"quote":   strconv.Quote,
+	"replace": func(_str, _old, _new string) string { return strings.Replace(_str, _old, _new, 1) },
+	"dict":    dict,
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// dict makes a map of its key, value arguments, for passing several values to a template
+func dict(_kvs ...interface{}) (map[string]interface{}, error) {
+	if len(_kvs)%2 != 0 {
+		return nil, errors.New("dict: odd number of arguments")
+	}
+	mm := make(map[string]interface{}, len(_kvs)/2)
+	for ii := 0; ii < len(_kvs); ii += 2 {
+		key, ok := _kvs[ii].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", _kvs[ii])
+		}
+		mm[key] = _kvs[ii+1]
+	}
+	return mm, nil
+}
+
+// genData is what the templates are executed with: the spec, the indexes made from it, and the options
+type genData struct {
+	Pkg, Caps   string
+	U           string // the suffix of member names, set by Underscore
+	Delim       string // the field separator as it goes inside string and rune literals
+	Sep         string // the field separator itself
+	HeaderStyle string
+	Module      string
+	Genutil     string // import path of genutil
+	PkgPath     string // import path of the generated package, for the test program
+	Hash        string // of the spec and the settings above, checked by Check
+
+	Cols     GENCSVElemPtrSlice // spec rows of the columns, hidden or not
+	Shown    GENCSVElemPtrSlice // spec rows of the columns that are not hidden
+	Headers  GENCSVElemPtrSlice // spec rows with finaltype header
+	Footers  GENCSVElemPtrSlice // spec rows with finaltype footer
+	HeadFoot GENCSVElemPtrSlice // Headers and Footers, in spec order
+	Inst     GENCSVElemPtrSlice // spec rows of instance variables
+	Sorts    GENCSVElemPtrSlice // instance variables with "sort" in their hasindex field
+	Imports  []string           // packages of instance variables of type foo.Bar, and time for time columns and the Time methods of timestamps
+
+	Indexes []indexMapElemPtr
+	Fav     *indexMapElem
+
+	Kinds       []string            // types of the columns that validCell checks
+	kinds       map[string]*colType // and what they are, by name
+	Locations   []string            // names of the locations time columns are parsed in, loaded once by the generated package
+	Optionals   bool                // some column is optional
+	Nullables   bool                // some column or header or footer member is nullable
+	NeedStrconv bool
+	NeedBytes   bool
+	NeedDigits  bool
+	NeedDecimal bool
+	NeedStamp   bool
+	NeedTime    bool
+	NeedNumFmt  bool // some column is written with a sign or thousands format, so formatNumber and unformatNumber are needed
+}
+
+// newGenData gathers from _spec, the indexes made from it, and _opt what the templates need
+func newGenData(_spec *Spec, _opt Options) (*genData, error) {
+	if len(_opt.Pkg) < 1 {
+		return nil, errors.New("Pkg must be specified")
+	}
+	if errs := _spec.Lint(); len(errs) > 0 {
+		return nil, errs
+	}
+	self := &genData{Pkg: _opt.Pkg, Caps: _opt.CapsPkg, HeaderStyle: _opt.HeaderStyle, Inst: _spec.Inst}
+	if len(self.Caps) < 1 {
+		self.Caps = strings.ToUpper(_opt.Pkg)
+	}
+	switch self.HeaderStyle {
+	case "":
+		self.HeaderStyle = "internal"
+	case "internal", "external":
+	default:
+		return nil, errors.New("HeaderStyle must be \"internal\" or \"external\", not " + _opt.HeaderStyle)
+	}
+	switch _opt.Underscore {
+	case "end":
+		self.U = "_"
+	case "no", "":
+		self.U = ""
+	default:
+		return nil, errors.New("Underscore must be one of \"end\" or \"no\", not " + _opt.Underscore)
+	}
+	self.Module, self.Genutil, self.PkgPath = _opt.Module, "genutil", _opt.ImportPrefix
+	if len(self.Module) > 0 {
+		self.Genutil = "github.com/LDCS/genutil"
+	}
+	switch {
+	case len(self.PkgPath) > 0:
+	case len(self.Module) > 0:
+		self.PkgPath = self.Module
+	default:
+		self.PkgPath = "anydset"
+	}
+	self.PkgPath = strings.TrimSuffix(self.PkgPath, "/") + "/" + self.Pkg
+	delim, sep, err := parseDelim(_opt.Delim)
+	if err != nil {
+		return nil, err
+	}
+	self.Delim, self.Sep = delim, string(sep)
+	log := _opt.Log
+	if log == nil {
+		log = io.Discard
+	}
+	if self.Indexes, self.Fav, err = makeIndexes(_spec.Cols, log); err != nil {
+		return nil, err
+	}
+
+	self.Hash = specHash(_spec, self)
+
+	kinds, zones := map[string]bool{}, map[string]bool{}
+	self.kinds = map[string]*colType{}
+	for _, row := range _spec.Cols {
+		switch {
+		case row.Header:
+			self.Headers = append(self.Headers, row)
+			self.HeadFoot = append(self.HeadFoot, row)
+		case row.Footer:
+			self.Footers = append(self.Footers, row)
+			self.HeadFoot = append(self.HeadFoot, row)
+		default:
+			if self.M(row) == "Overflow_" {
+				return nil, errors.New("column " + row.Name + " would be the member Overflow_, which holds the columns kept by Passthrough")
+			}
+			self.Cols = append(self.Cols, row)
+			self.Optionals = self.Optionals || row.Optional
+			if !row.Hidden {
+				self.Shown = append(self.Shown, row)
+			}
+		}
+		self.Nullables = self.Nullables || row.Nullable
+		ct := self.typeOf(row)
+		if ct == nil {
+			return nil, errors.New("unhandled Type_ of field=" + row.Type)
+		}
+		if ct.Time != nil {
+			self.NeedTime = true
+			if ct.Time.Zone != "" && !zones[ct.Time.Zone] {
+				zones[ct.Time.Zone] = true
+				self.Locations = append(self.Locations, ct.Time.Zone)
+			}
+		}
+		self.NeedStrconv = self.NeedStrconv || ct.Strconv
+		self.NeedBytes = self.NeedBytes || ct.Bytes
+		self.NeedDecimal = self.NeedDecimal || ct.Decimal
+		self.NeedStamp = self.NeedStamp || ct.Stamp
+		self.NeedNumFmt = self.NeedNumFmt || self.Unformat(row)
+		if !(row.Header || row.Footer) {
+			kinds[ct.Name] = true
+			self.kinds[ct.Name] = ct
+			self.NeedDigits = self.NeedDigits || ct.Digits
+		}
+	}
+	for _, rows := range []GENCSVElemPtrSlice{self.Cols, self.HeadFoot} {
+		names := map[string]bool{}
+		for _, row := range rows {
+			names[self.M(row)] = true
+		}
+		for _, row := range rows {
+			if self.typeOf(row).Stamp && names[self.TimeName(row)] {
+				return nil, errors.New("timestamp " + row.Name + " has the method " + self.TimeName(row) + ", which clashes with a member of that name")
+			}
+		}
+	}
+	for _, ct := range colTypes {
+		if kinds[ct.Name] && ct.Valid != "" {
+			self.Kinds = append(self.Kinds, ct.Name)
+		}
+	}
+	for _, row := range self.Cols { // then the types made from parameters, in spec order
+		if ct := self.typeOf(row); strings.Contains(ct.Name, "(") && kinds[ct.Name] && ct.Valid != "" {
+			kinds[ct.Name] = false
+			self.Kinds = append(self.Kinds, ct.Name)
+		}
+	}
+	done := map[string]bool{}
+	if self.NeedStamp || self.NeedTime {
+		done["time"] = true
+		self.Imports = append(self.Imports, "time")
+	}
+	for _, yrow := range _spec.Inst {
+		if yrow.Hasindex == "sort" {
+			self.Sorts = append(self.Sorts, yrow)
+		}
+		if dot := strings.Index(yrow.OutType, "."); dot > 0 && !done[yrow.OutType[:dot]] {
+			done[yrow.OutType[:dot]] = true
+			self.Imports = append(self.Imports, yrow.OutType[:dot])
+		}
+	}
+	return self, nil
+}
+
+// typeOf returns the column type of spec row _row, which for a time column with a format is written in that layout,
+// and for a column whose type has further Formats, in the one its format names
+func (self *genData) typeOf(_row *GENCSVElem) *colType {
+	ct := lookupType(_row.Type)
+	if ct != nil && ct.Time != nil && _row.Numfmt != "" {
+		ct = writtenAs(ct, _row.Numfmt)
+	} else if ct != nil && ct.Formats[_row.Numfmt] != "" {
+		named := *ct
+		named.Format = ct.Formats[_row.Numfmt]
+		ct = &named
+	}
+	return ct
+}
+
+// Kind returns the name of the column type of spec row _row, as validCell knows it
+func (self *genData) Kind(_row *GENCSVElem) string {
+	return self.typeOf(_row).Name
+}
+
+// M returns the name of the member of spec row _row
+func (self *genData) M(_row *GENCSVElem) string {
+	return _row.Name + self.U
+}
+
+// Member returns the expression for the member of spec row _row, in the struct _recv
+func (self *genData) Member(_recv string, _row *GENCSVElem) string {
+	return _recv + "." + self.M(_row)
+}
+
+// Extras returns the further members that the type of spec row _row adds
+func (self *genData) Extras(_row *GENCSVElem) (extras []xatt) {
+	for _, xx := range self.typeOf(_row).Extra {
+		extras = append(extras, xatt{_row.Name + xx.Xname + self.U, xx.Xtype})
+	}
+	return extras
+}
+
+// members returns the member of _recv for spec row _row, followed by its Extra members
+func (self *genData) members(_recv string, _row *GENCSVElem) []string {
+	members := []string{self.Member(_recv, _row)}
+	for _, xx := range self.Extras(_row) {
+		members = append(members, _recv+"."+xx.Xname)
+	}
+	return members
+}
+
+// Stamp returns the members of _recv for the timestamp spec row _row, its date, time, milliseconds and zone offset, as arguments
+func (self *genData) Stamp(_recv string, _row *GENCSVElem) string {
+	return strings.Join(self.members(_recv, _row), ", ")
+}
+
+// TimeName returns the name of the method that returns the timestamp spec row _row as a time.Time
+func (self *genData) TimeName(_row *GENCSVElem) string {
+	return _row.Name + "Time"
+}
+
+// NullName returns the name of the member that tells whether the member of the nullable spec row _row is null
+func (self *genData) NullName(_row *GENCSVElem) string {
+	return _row.Name + "_null" + self.U
+}
+
+// numFormat returns the format of spec row _row, or nil if it has none
+func (self *genData) numFormat(_row *GENCSVElem) *numFormat {
+	if ct := self.typeOf(_row); _row.Numfmt == "" || ct.Time != nil || ct.Formats[_row.Numfmt] != "" {
+		return nil
+	}
+	nf, _ := parseNumFormat(_row.Numfmt, _row.Type) // checked by Lint
+	return nf
+}
+
+// Unformat tests that the cells of spec row _row are written with a sign or thousands format, which unformatNumber undoes before they are loaded
+func (self *genData) Unformat(_row *GENCSVElem) bool {
+	nf := self.numFormat(_row)
+	return nf != nil && (nf.Sign != 0 || nf.Thousands)
+}
+
+// Number returns the expression that formats the member of _recv for spec row _row as its format says, without quoting
+func (self *genData) Number(_recv string, _row *GENCSVElem) string {
+	number := fmt.Sprintf(self.typeOf(_row).Format, self.Member(_recv, _row))
+	if tl := self.typeOf(_row).Time; tl != nil {
+		number = fmt.Sprintf("formatTime(%s, %q, %s)", self.Member(_recv, _row), tl.Write, tl.Location)
+	}
+	if nf := self.numFormat(_row); nf != nil {
+		if nf.Verb != 0 {
+			number = fmt.Sprintf("strconv.FormatFloat(%s, '%c', %d, 64)", self.Member(_recv, _row), nf.Verb, nf.Prec)
+		}
+		if nf.Sign != 0 || nf.Thousands {
+			number = fmt.Sprintf("formatNumber(%s, %t, %q)", number, nf.Thousands, nf.Sign)
+		}
+	}
+	return number
+}
+
+// Load returns the statement that converts the cell expression _cell into the member(s) of _recv for spec row _row
+func (self *genData) Load(_recv string, _row *GENCSVElem, _cell string) string {
+	if self.Unformat(_row) {
+		_cell = "unformatNumber(" + _cell + ")"
+	}
+	args := []interface{}{self.Member(_recv, _row), _cell}
+	for _, xx := range self.Extras(_row) {
+		args = append(args, _recv+"."+xx.Xname)
+	}
+	load := fmt.Sprintf(self.typeOf(_row).Load, args...)
+	if _row.Nullable {
+		load = _recv + "." + self.NullName(_row) + " = isNull(" + _cell + ")\n" + load
+	}
+	return load
+}
+
+// Cell returns the expression for the cell of spec row _row, the column number _ii, in the cells of a row.
+// An optional column that is missing gives its default
+func (self *genData) Cell(_row *GENCSVElem, _ii int) string {
+	if _row.Optional {
+		return fmt.Sprintf("cellOr(cells, self.colpos_[%d], %s)", _ii, strconv.Quote(_row.Default))
+	}
+	return fmt.Sprintf("cellAt(cells, self.colpos_[%d])", _ii)
+}
+
+// Format returns the expression that formats the member of _recv for spec row _row as a cell, as its format says, which is empty if it is null
+func (self *genData) Format(_recv string, _row *GENCSVElem) string {
+	args := []interface{}{}
+	for _, member := range self.members(_recv, _row) {
+		args = append(args, member)
+	}
+	format := fmt.Sprintf(self.typeOf(_row).Format, args...)
+	if nf := self.numFormat(_row); nf != nil {
+		format = self.Number(_recv, _row)
+		if nf.Thousands { // the commas may be the separator
+			format = "quoteCell(" + format + ")"
+		}
+	}
+	if _row.Nullable {
+		format = "nullOr(" + _recv + "." + self.NullName(_row) + ", " + format + ")"
+	}
+	return format
+}
+
+// Clear returns the value ClearRow gives the member of spec row _row
+func (self *genData) Clear(_row *GENCSVElem) string {
+	return self.typeOf(_row).Clear
+}
+
+// Aliases returns the literal of the name, headerstring and aliases of spec row _row, folded as the generated mapHeader matches them
+func (self *genData) Aliases(_row *GENCSVElem) string {
+	folded, seen := []string{}, map[string]bool{}
+	for _, name := range append([]string{_row.Name, _row.Headerstring}, _row.Aliases...) {
+		if key := foldName(name); len(key) > 0 && !seen[key] {
+			folded, seen[key] = append(folded, strconv.Quote(key)), true
+		}
+	}
+	return "[]string{" + strings.Join(folded, ", ") + "}"
+}
+
+// Valid returns the body of the validCell case for the type _kind
+func (self *genData) Valid(_kind string) string {
+	return self.kinds[_kind].Valid
+}
+
+// Key returns the expression for the key of the row _recv in the index _im, joining the parts of a multi-column key with its separator
+func (self *genData) Key(_recv string, _im *indexMapElem) string {
+	parts := make([]string, len(_im.Rows))
+	for ii, name := range _im.Rows {
+		parts[ii] = _recv + "." + name + self.U
+	}
+	return strings.Join(parts, " + "+strconv.Quote(_im.Sep)+" + ")
+}
+
+// HasIndexType tests whether any index is keyed by the type _type
+func (self *genData) HasIndexType(_type string) bool {
+	for _, im := range self.Indexes {
+		if im.Type == _type {
+			return true
+		}
+	}
+	return false
+}
+
+// Hdr returns the generated []string literal of the cells of the header row, each quoted as the generated quoteCell quotes it
+func (self *genData) Hdr(_withHidden bool) string {
+	cells := []string{}
+	for _, name := range self.hdrNames(_withHidden) {
+		cells = append(cells, strconv.Quote(self.quoteCell(name)))
+	}
+	return "[]string{" + strings.Join(cells, ", ") + "}"
+}
+
+// quoteCell returns _str as the generated quoteCell writes it to a cell: double-quoted if it holds the separator, a quote or a newline
+func (self *genData) quoteCell(_str string) string {
+	if !strings.Contains(_str, self.Sep) && !strings.ContainsAny(_str, "\"\r\n") {
+		return _str
+	}
+	return `"` + strings.Replace(_str, `"`, `""`, -1) + `"`
+}
+
+// hdrNames returns the column names of the header row
+func (self *genData) hdrNames(_withHidden bool) []string {
+	names := []string{}
+	for _, row := range self.Cols {
+		if row.Hidden && !_withHidden {
+			continue
+		}
+		names = append(names, self.hdrName(row))
+	}
+	return names
+}
+
+// hdrName returns the name of the column of spec row _row in the header row
+func (self *genData) hdrName(_row *GENCSVElem) string {
+	if self.HeaderStyle == "external" && len(_row.Headerstring) > 0 {
+		return _row.Headerstring
+	}
+	return _row.Name // without a headerstring, the column is named by its member, as mapHeader expects
+}
+
+// numSample is the number of rows in the synthetic file of the generated test
+const numSample = 3
+
+// NumSample returns the number of rows in the synthetic file of the generated test
+func (self *genData) NumSample() int {
+	return numSample
+}
+
+// sampleCells returns the cells of the row number _ii of the synthetic file, one for each of _rows.
+// A rowcount footer gets the number of rows, and nullable columns are null in row 2
+func (self *genData) sampleCells(_rows GENCSVElemPtrSlice, _ii int) string {
+	cells := make([]string, len(_rows))
+	for jj, row := range _rows {
+		if row.FooterCount {
+			cells[jj] = strconv.Itoa(numSample)
+		} else if row.Nullable && _ii == 2 { // left empty
+		} else if tl := self.typeOf(row).Time; tl != nil {
+			cells[jj] = self.quoteCell(formatTime(time.Date(2016, 1, _ii, 9, 30, _ii, 0, tl.loc), tl.Layout, tl.loc))
+		} else if sample := self.typeOf(row).Sample; sample != "" {
+			cells[jj] = fmt.Sprintf(sample, _ii, _ii%2 == 1)
+		}
+	}
+	return strings.Join(cells, self.Sep)
+}
+
+// overflowName is the column that is not in the spec, which the generated test adds at position 1 of the synthetic file to test Passthrough
+const overflowName = "Not In Spec"
+
+// OverflowName returns the column that is not in the spec of the generated test
+func (self *genData) OverflowName() string {
+	return overflowName
+}
+
+// SampleFile returns the synthetic file of the generated test: the preamble, the row of column names, numSample rows and the trailer.
+// Optional columns are left out, so that the test loads their defaults, and columns are named in upper case (by their first alias, if any).
+// With _overflow, the column overflowName is added at position 1, holding x1, x2...
+func (self *genData) SampleFile(_overflow bool) string {
+	var buf strings.Builder
+	if len(self.Headers) > 0 {
+		buf.WriteString(self.sampleCells(self.Headers, 1) + "\n")
+	}
+	rows, names := GENCSVElemPtrSlice{}, []string{}
+	for _, row := range self.Shown {
+		switch {
+		case row.Optional:
+			continue
+		case len(row.Aliases) > 0:
+			names = append(names, self.quoteCell(strings.ToUpper(row.Aliases[0])))
+		default:
+			names = append(names, self.quoteCell(strings.ToUpper(self.hdrName(row))))
+		}
+		rows = append(rows, row)
+	}
+	insert := func(_line, _cell string) string {
+		if !_overflow {
+			return _line
+		}
+		cells := strings.SplitN(_line, self.Sep, 2)
+		return strings.Join(append(cells[:1], append([]string{_cell}, cells[1:]...)...), self.Sep)
+	}
+	buf.WriteString(insert(strings.Join(names, self.Sep), overflowName) + "\n")
+	for ii := 1; ii <= numSample; ii++ {
+		buf.WriteString(insert(self.sampleCells(rows, ii), "x"+strconv.Itoa(ii)) + "\n")
+	}
+	if len(self.Footers) > 0 {
+		buf.WriteString(self.sampleCells(self.Footers, 1) + "\n")
+	}
+	return buf.String()
+}
+
+// execTemplate executes the template _name with _data, and returns the output, formatted by go/format when _gofmt is set.
+// Output that does not parse is returned unformatted, along with the error, to help locate the error
+func execTemplate(_name string, _data *genData, _gofmt bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, _name, _data); err != nil {
+		return nil, err
+	}
+	if !_gofmt {
+		return buf.Bytes(), nil
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), err
+	}
+	return formatted, nil
+}
@@ -0,0 +1,382 @@
+package gencsv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// colType describes how the generated package holds, loads, writes, clears and checks a column of one spec type.
+// Adding a column type only needs a new entry in colTypes
+type colType struct {
+	Name    string            // the type as written in the spec file
+	OutType string            // golang type of the member
+	Load    string            // statement setting member %[1]s from the cell %[2]s (a bslice), and the Extra members from %[3]s on
+	Format  string            // expression writing member %[1]s, and the Extra members from %[2]s on, as the text of a cell
+	Formats map[string]string // further Format expressions, chosen by "format:NAME" in the finaltype of the column
+	Clear   string            // value ClearRow gives the member
+	Valid   string            // body of the validCell case, testing the trimmed non-empty text str of a cell, or "" to accept any
+	Sample  string            // format of a cell of the generated test, from the row number %[1]d and its oddness %[2]t, or "" to leave it empty
+	Extra   []xatt            // further numeric members, named after the column with Xname appended, which ClearRow sets to 0
+	Strconv bool              // Format or Valid use strconv
+	Bytes   bool              // Load uses bytes
+	Digits  bool              // Valid uses isDigits
+	Decimal bool              // the column is held as a Decimal
+	Stamp   bool              // the column is a timestamp, written by formatStamp
+	Time    *timeLayout       // set for a time column, parsed and written by parseTime and formatTime
+
+	// Fits, if set, tests that the trimmed non-empty text _str parses as the type, as Valid does in the generated package,
+	// so that Lint can check a default; otherwise fitsKind does, for the types it knows
+	Fits func(_str string) bool
+
+	// Make, if set, makes the type from the parameters written in parentheses after the name, as in decimal(18,4)
+	Make func(_params []string) (*colType, error)
+}
+
+// colTypes lists the column types, in the order of the cases of the generated validCell
+var colTypes = []*colType{
+	{
+		Name:    "string",
+		OutType: "string",
+		Load:    "%[1]s = strings.TrimSpace(string(%[2]s))",
+		Format:  "quoteCell(%[1]s)",
+		Clear:   `""`,
+		Sample:  "s%[1]d",
+	},
+	{
+		Name:    "bool",
+		OutType: "bool",
+		Load:    "%[1]s = genutil.ToBool(strings.TrimSpace(string(%[2]s)), false)",
+		Format:  "strconv.FormatBool(%[1]s)",
+		Clear:   "false",
+		Valid:   "_, err := strconv.ParseBool(str)\nreturn err == nil",
+		Sample:  "%[2]t",
+		Strconv: true,
+	},
+	{
+		Name:    "int64",
+		OutType: "int64",
+		Load:    "%[1]s = genutil.ToInt(strings.TrimSpace(string(%[2]s)), 0)",
+		Format:  "strconv.FormatInt(%[1]s, 10)",
+		Clear:   "0",
+		Valid:   "_, err := strconv.ParseInt(str, 10, 64)\nreturn err == nil",
+		Sample:  "%[1]d",
+		Strconv: true,
+	},
+	{
+		Name:    "float64",
+		OutType: "float64",
+		Load:    "%[1]s = genutil.ToFloat(bytes.TrimSpace(%[2]s))",
+		Format:  "strconv.FormatFloat(%[1]s, 'f', 6, 64)",
+		Clear:   "0.0",
+		Valid:   "_, err := strconv.ParseFloat(str, 64)\nreturn err == nil",
+		Sample:  "%[1]d.5",
+		Strconv: true,
+		Bytes:   true,
+	},
+	{
+		Name:    "yyyymmdd",
+		OutType: "int64",
+		Load:    "%[1]s = genutil.ToInt(strings.TrimSpace(string(%[2]s)), 19000101)",
+		Format:  "strconv.FormatInt(%[1]s, 10)",
+		Clear:   "19000101",
+		Valid:   "return len(str) == 8 && isDigits(str)",
+		Sample:  "201601%02[1]d",
+		Strconv: true,
+		Digits:  true,
+	},
+	{
+		Name:    "yyyy_mm_dd",
+		OutType: "int64",
+		Load: "if ymd := bytes.TrimSpace(%[2]s); len(ymd) == 8 { // as written back\n" +
+			"%[1]s = genutil.ToInt(string(ymd), 19000101)\n} else {\n%[1]s = genutil.YYYY_MM_DD2yyyymmdd(ymd)\n}",
+		Format:  "fmt.Sprint(%[1]s)",
+		Formats: map[string]string{"YYYY-MM-DD": `fmt.Sprintf("%%04d-%%02d-%%02d", %[1]s/10000, %[1]s/100%%100, %[1]s%%100)`},
+		Clear:   "19000101",
+		Valid:   "return len(str) == 8 && isDigits(str) || len(str) == 10 && isDigits(str[0:4]) && isDigits(str[5:7]) && isDigits(str[8:10]) && !isDigits(str[4:5]) && !isDigits(str[7:8])",
+		Sample:  "2016-01-%02[1]d",
+		Bytes:   true,
+		Digits:  true,
+		Fits:    func(_str string) bool { return fitsKind("yyyymmdd", _str) || fitsKind("yyyy_mm_dd", _str) }, // as it loads
+	},
+	{
+		Name:    "YYYY_MM_DD_HH_MM_SS_mmm_zz",
+		OutType: "int64",
+		Load:    "%[1]s, %[3]s, %[4]s, %[5]s = genutil.YYYY_MM_DD_HH_MM_SS_mmm_zz2yyyymmdd_hhmmss_mmm_zz(bytes.TrimSpace(%[2]s))",
+		Format:  "formatStamp(%[1]s, %[2]s, %[3]s, %[4]s)",
+		Clear:   "19000101",
+		Valid:   "return len(str) >= 10 && isDigits(str[0:4]) && isDigits(str[5:7]) && isDigits(str[8:10]) && !isDigits(str[4:5]) && !isDigits(str[7:8])",
+		Sample:  "2016-01-%02[1]d 09:30:%02[1]d.250+05",
+		Extra:   []xatt{{"_hhmmss", "int64"}, {"_mmm", "int64"}, {"_zz", "int64"}},
+		Bytes:   true,
+		Digits:  true,
+		Stamp:   true,
+	},
+	{
+		Name: "decimal",
+		Make: makeDecimal,
+	},
+	{
+		Name: "time",
+		Make: makeTime,
+	},
+}
+
+// fits tests that the trimmed non-empty text _str parses as the type, as a default must
+func (self *colType) fits(_str string) bool {
+	if self.Fits != nil {
+		return self.Fits(_str)
+	}
+	for _, kind := range inferKinds {
+		if kind == self.Name {
+			return fitsKind(kind, _str)
+		}
+	}
+	return true
+}
+
+// lookupType returns the column type named _name, or nil if there is none, or its parameters are bad
+func lookupType(_name string) *colType {
+	ct, _ := makeType(_name)
+	return ct
+}
+
+// makeType returns the column type named _name, made from its parameters if it has any, or an error telling why there is none
+func makeType(_name string) (*colType, error) {
+	base, params := _name, []string(nil)
+	if open := strings.IndexByte(_name, '('); open > 0 && strings.HasSuffix(_name, ")") {
+		base, params = _name[:open], strings.Split(_name[open+1:len(_name)-1], ",")
+	}
+	for _, ct := range colTypes {
+		if ct.Name != base {
+			continue
+		}
+		switch {
+		case ct.Make != nil && params != nil:
+			return ct.Make(params)
+		case ct.Make != nil:
+			return nil, errors.New("type " + base + " needs parameters, as in " + base + "(...)")
+		case params != nil:
+			return nil, errors.New("type " + base + " has no parameters")
+		}
+		return ct, nil
+	}
+	names := []string{}
+	for _, ct := range colTypes {
+		names = append(names, ct.Name)
+	}
+	return nil, errors.New("unknown type " + _name + ", want one of " + strings.Join(names, ", "))
+}
+
+// makeDecimal makes the type decimal(p,s), of exact numbers of at most p digits, s of them after the point, held as a Decimal
+func makeDecimal(_params []string) (*colType, error) {
+	if len(_params) != 2 {
+		return nil, errors.New("decimal needs 2 parameters, as in decimal(18,4)")
+	}
+	prec, err1 := strconv.Atoi(strings.TrimSpace(_params[0]))
+	scale, err2 := strconv.Atoi(strings.TrimSpace(_params[1]))
+	if err1 != nil || err2 != nil || prec < 1 || prec > 18 || scale < 0 || scale > prec {
+		return nil, fmt.Errorf("decimal(%s) needs a precision of 1 to 18 digits, and a scale of 0 to the precision", strings.Join(_params, ","))
+	}
+	sample := "%[1]d"
+	if prec == scale {
+		sample = "0.%[1]d"
+	} else if scale > 0 {
+		sample += ".5"
+	}
+	return &colType{
+		Name:    fmt.Sprintf("decimal(%d,%d)", prec, scale),
+		OutType: "Decimal",
+		Load:    fmt.Sprintf("%%[1]s, _ = parseDecimal(strings.TrimSpace(string(%%[2]s)), %d, %d)", prec, scale),
+		Format:  "%[1]s.String()",
+		Clear:   "Decimal{}",
+		Valid:   fmt.Sprintf("_, ok := parseDecimal(str, %d, %d)\nreturn ok", prec, scale),
+		Sample:  sample,
+		Strconv: true,
+		Decimal: true,
+		Fits:    func(_str string) bool { return fitsDecimal(_str, prec, scale) },
+	}, nil
+}
+
+// fitsDecimal tests that _str is a number of at most _prec digits, at most _scale of them after the point, as the generated parseDecimal does
+func fitsDecimal(_str string, _prec, _scale int) bool {
+	str := strings.TrimLeft(_str, "+-")
+	if len(_str)-len(str) > 1 {
+		return false
+	}
+	whole, frac := str, ""
+	if dot := strings.IndexByte(str, '.'); dot >= 0 {
+		whole, frac = str[:dot], str[dot+1:]
+	}
+	if len(whole)+len(frac) < 1 || len(frac) > _scale || (whole != "" && !isDigits(whole)) || (frac != "" && !isDigits(frac)) {
+		return false
+	}
+	return len(strings.TrimLeft(whole, "0")) <= _prec-_scale
+}
+
+// timeLayout is how a time column is parsed and written
+type timeLayout struct {
+	Layout   string // golang layout the cells are parsed with, or epoch or epochms for seconds or milliseconds since 1970
+	Write    string // layout the cells are written with, which is Layout unless the format of the column gives another
+	Zone     string // name of the location the cells are parsed in, looked up in the generated locations, or "" for UTC or Local
+	Location string // expression of that location in the generated package
+	loc      *time.Location
+}
+
+// makeTime makes the type time(layout[,tz]), of times parsed with the layout in the location tz (UTC if none), held as a time.Time.
+// As the layout may hold commas, only a last parameter that looks like a location name is taken as tz
+func makeTime(_params []string) (*colType, error) {
+	layout, tz := strings.Join(_params, ","), ""
+	if last := strings.TrimSpace(_params[len(_params)-1]); len(_params) > 1 && isZoneName(last) {
+		layout, tz = strings.Join(_params[:len(_params)-1], ","), last
+	}
+	name := "time(" + layout + ")"
+	if tz != "" {
+		name = "time(" + layout + "," + tz + ")"
+	}
+	if strings.TrimSpace(layout) == "" || strings.ContainsAny(layout, "()") {
+		return nil, errors.New(name + " needs a layout without parentheses, as in time(01/02/2006) or time(epochms)")
+	}
+	tl := &timeLayout{Layout: layout, Write: layout, Location: "time.UTC", loc: time.UTC}
+	switch tz {
+	case "", "UTC":
+	case "Local":
+		tl.Location, tl.loc = "time.Local", time.Local
+	default:
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, errors.New(name + " has an unknown location " + tz)
+		}
+		tl.Zone, tl.Location, tl.loc = tz, "locations["+strconv.Quote(tz)+"]", loc
+	}
+	return timeType(name, tl), nil
+}
+
+// isZoneName tests that _str looks like the name of a location, as in UTC or America/New_York, rather than a piece of a layout
+func isZoneName(_str string) bool {
+	if len(_str) < 1 || _str[0] < 'A' || _str[0] > 'Z' {
+		return false
+	}
+	for ii := 0; ii < len(_str); ii++ {
+		if cc := _str[ii]; !(cc >= 'A' && cc <= 'Z' || cc >= 'a' && cc <= 'z' || cc >= '0' && cc <= '9' || strings.IndexByte("/_-+", cc) >= 0) {
+			return false
+		}
+	}
+	return !strings.Contains(_str, "Jan") && !strings.Contains(_str, "Mon") && !strings.Contains(_str, "MST")
+}
+
+// timeType returns the time type named _name, laid out as _tl says. If it is written in another layout, that one loads too,
+// so that the files it writes load back, and its name tells both
+func timeType(_name string, _tl *timeLayout) *colType {
+	layouts := strconv.Quote(_tl.Layout)
+	if _tl.Write != _tl.Layout {
+		_name, layouts = _name+" or "+_tl.Write, layouts+", "+strconv.Quote(_tl.Write)
+	}
+	pct := strings.NewReplacer("%", "%%") // Load and Format are formats themselves
+	fits := func(_str string) bool {
+		_, err := parseTime(_str, _tl.loc, _tl.Layout, _tl.Write)
+		return err == nil
+	}
+	return &colType{
+		Name:    _name,
+		OutType: "time.Time",
+		Load:    "%[1]s, _ = parseTime(strings.TrimSpace(string(%[2]s)), " + _tl.Location + ", " + pct.Replace(layouts) + ")",
+		Format:  "quoteCell(formatTime(%[1]s, " + pct.Replace(strconv.Quote(_tl.Write)) + ", " + _tl.Location + "))",
+		Clear:   "time.Time{}",
+		Valid:   "_, err := parseTime(str, " + _tl.Location + ", " + layouts + ")\nreturn err == nil",
+		Strconv: true,
+		Time:    _tl,
+		Fits:    fits,
+	}
+}
+
+// writtenAs returns the time type _ct written with the layout _write
+func writtenAs(_ct *colType, _write string) *colType {
+	tl := *_ct.Time
+	tl.Write = _write
+	return timeType(strings.SplitN(_ct.Name, " or ", 2)[0], &tl)
+}
+
+// parseTime parses _str with the first of _layouts that fits, in the location _loc, as the generated parseTime does
+func parseTime(_str string, _loc *time.Location, _layouts ...string) (time.Time, error) {
+	var err error
+	for _, layout := range _layouts {
+		var tt time.Time
+		switch layout {
+		case "epoch", "epochms":
+			var num int64
+			if num, err = strconv.ParseInt(_str, 10, 64); err == nil && layout == "epoch" {
+				tt = time.Unix(num, 0)
+			} else if err == nil {
+				tt = time.Unix(num/1000, num%1000*int64(time.Millisecond))
+			}
+		default:
+			tt, err = time.ParseInLocation(layout, _str, _loc)
+		}
+		if err == nil {
+			return tt.In(_loc), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// formatTime writes _tt with _layout as the generated formatTime does, an empty string for the zero time
+func formatTime(_tt time.Time, _layout string, _loc *time.Location) string {
+	switch {
+	case _tt.IsZero():
+		return ""
+	case _layout == "epoch":
+		return strconv.FormatInt(_tt.Unix(), 10)
+	case _layout == "epochms":
+		return strconv.FormatInt(_tt.UnixNano()/int64(time.Millisecond), 10)
+	}
+	return _tt.In(_loc).Format(_layout)
+}
+
+// numFormat is how a numeric column is written, as declared by "format:[+|(][,][.N][f|e|g]" in its finaltype
+type numFormat struct {
+	Sign      byte // '+' to write the sign of positive numbers too, '(' to write negative ones in parentheses, or 0
+	Thousands bool // separate thousands with commas
+	Prec      int  // decimals (significant digits for g), or -1 for the fewest that read back the same
+	Verb      byte // 'f' fixed, 'e' scientific or 'g' shortest, or 0 to keep the format of the type
+}
+
+// parseNumFormat parses the format _str of a column of type _type
+func parseNumFormat(_str, _type string) (*numFormat, error) {
+	nf, str := &numFormat{Prec: -1}, _str
+	if len(str) > 0 && (str[0] == '+' || str[0] == '(') {
+		nf.Sign, str = str[0], str[1:]
+	}
+	if strings.HasPrefix(str, ",") {
+		nf.Thousands, str = true, str[1:]
+	}
+	if strings.HasPrefix(str, ".") {
+		digits := strings.TrimRight(str[1:], "feg")
+		prec, err := strconv.Atoi(digits)
+		if err != nil || prec < 0 || prec > 30 {
+			return nil, errors.New("format " + _str + " has a bad precision ." + digits)
+		}
+		nf.Prec, str = prec, str[1+len(digits):]
+	}
+	if len(str) == 1 && strings.Contains("feg", str) {
+		nf.Verb, str = str[0], ""
+	}
+	if len(str) > 0 {
+		return nil, errors.New("format " + _str + " is not [+|(][,][.N][f|e|g]")
+	}
+	if nf.Verb == 0 && nf.Prec >= 0 {
+		nf.Verb = 'f'
+	}
+	switch {
+	case _type == "float64":
+	case _type == "int64" || strings.HasPrefix(_type, "decimal("):
+		if nf.Verb != 0 {
+			return nil, errors.New("format " + _str + " of " + _type + " column can only set the sign and thousands, as in +,")
+		}
+	default:
+		return nil, errors.New("format " + _str + " is only for float64, int64 and decimal columns (or the layout of a time column), not " + _type)
+	}
+	return nf, nil
+}